@@ -0,0 +1,24 @@
+package common
+
+import (
+	"encoding/base64"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CopyMsg asks root to put Text on the clipboard.
+type CopyMsg struct {
+	Text string
+}
+
+func Copy(text string) tea.Cmd {
+	return func() tea.Msg {
+		return CopyMsg{Text: text}
+	}
+}
+
+// Sequence is the OSC 52 escape that sets the clipboard, which works over
+// SSH too. Terminals that don't allow it ignore the request.
+func (c CopyMsg) Sequence() string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(c.Text)) + "\a"
+}
@@ -4,6 +4,18 @@ import tea "github.com/charmbracelet/bubbletea"
 
 type BackToRootMsg struct{}
 
+// Typer is implemented by models that can be capturing free text. While
+// Typing reports true, single-key shortcuts like q are left to the model.
+type Typer interface {
+	Typing() bool
+}
+
+// Stopper is implemented by models that run processes of their own. Root
+// calls Stop before quitting so none of them outlive go-live.
+type Stopper interface {
+	Stop()
+}
+
 func BackToRoot() tea.Cmd {
 	return func() tea.Msg {
 		return BackToRootMsg{}
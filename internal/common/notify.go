@@ -0,0 +1,33 @@
+package common
+
+import "strings"
+
+// NotifyMsg asks for the terminal bell and a desktop notification. Root
+// writes it out whatever is on screen, so any model can send one.
+type NotifyMsg struct {
+	Title string
+	Body  string
+}
+
+// Sequence is the escapes that ring the bell and ask for the notification.
+// Terminals differ in which they understand, so both OSC 9 (iTerm2, Windows
+// Terminal, kitty) and OSC 777 (foot, Ghostty, rxvt) are sent, the rest
+// ignore them.
+func (n NotifyMsg) Sequence() string {
+	title, body := oscText(n.Title), oscText(n.Body)
+
+	return "\a" +
+		"\x1b]9;" + title + ": " + body + "\a" +
+		"\x1b]777;notify;" + title + ";" + body + "\a"
+}
+
+// oscText drops control characters, which would end the sequence early, and
+// semicolons, which separate OSC 777 fields.
+func oscText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == ';' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// StateDir returns the directory go-live keeps its logs and saved results
+// in, joined with elem and created if it doesn't exist yet. It follows
+// $XDG_STATE_HOME and falls back to ~/.local/state.
+func StateDir(elem ...string) (string, error) {
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".local", "state")
+	}
+
+	dir := filepath.Join(append([]string{base, "go-live"}, elem...)...)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// ConfigDir returns the directory go-live reads its configuration from,
+// joined with elem. It follows os.UserConfigDir, e.g. ~/.config/go-live.
+func ConfigDir(elem ...string) (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(append([]string{base, "go-live"}, elem...)...), nil
+}
@@ -0,0 +1,83 @@
+// Package directive parses the lines long-running scripts print to report
+// their progress. Anything that runs a script, the Utils progress tool or a
+// deploy step, can use it to drive a progress bar from the script's output:
+//
+//	::progress 40 Building assets
+//	::progress 40% Building assets
+//	::progress 3/8 Migrating tables
+//	::status Waiting for health checks
+//
+// Percentages are clamped to 0-100. Lines that aren't directives, including
+// malformed ones, are ordinary output.
+package directive
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Progress is a parsed directive.
+type Progress struct {
+	// Percent is between 0 and 1, or -1 when only the status changed.
+	Percent float64
+	Status  string
+}
+
+// Parse reads a directive from line, reporting false if it isn't one.
+func Parse(line string) (Progress, bool) {
+	line = strings.TrimSpace(line)
+
+	if rest, ok := strings.CutPrefix(line, "::status"); ok && (rest == "" || rest[0] == ' ') {
+		return Progress{Percent: -1, Status: strings.TrimSpace(rest)}, true
+	}
+
+	rest, ok := strings.CutPrefix(line, "::progress ")
+	if !ok {
+		return Progress{}, false
+	}
+
+	value, status, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	percent, ok := parsePercent(value)
+	if !ok {
+		return Progress{}, false
+	}
+
+	return Progress{Percent: percent, Status: strings.TrimSpace(status)}, true
+}
+
+// parsePercent reads 40, 40% or 3/8 as a fraction of one.
+func parsePercent(s string) (float64, bool) {
+	p := 0.0
+
+	if done, total, ok := strings.Cut(s, "/"); ok {
+		d, ok := parseNumber(done)
+		if !ok {
+			return 0, false
+		}
+		t, ok := parseNumber(total)
+		if !ok || t <= 0 {
+			return 0, false
+		}
+		p = d / t
+	} else {
+		n, ok := parseNumber(strings.TrimSuffix(s, "%"))
+		if !ok {
+			return 0, false
+		}
+		p = n / 100
+	}
+
+	return min(max(p, 0), 1), true
+}
+
+// parseNumber is strconv.ParseFloat without NaN and infinities, which it
+// spells out happily and min and max can't clamp.
+func parseNumber(s string) (float64, bool) {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, false
+	}
+
+	return n, true
+}
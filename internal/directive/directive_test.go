@@ -0,0 +1,53 @@
+package directive
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want Progress
+		ok   bool
+	}{
+		{"::progress 40", Progress{Percent: 0.4}, true},
+		{"::progress 40% Building assets", Progress{Percent: 0.4, Status: "Building assets"}, true},
+		{"  ::progress 3/8   Migrating tables  ", Progress{Percent: 0.375, Status: "Migrating tables"}, true},
+		{"::progress 150", Progress{Percent: 1}, true},
+		{"::progress -5", Progress{Percent: 0}, true},
+		{"::progress 9/8", Progress{Percent: 1}, true},
+		{"::status Waiting for health checks", Progress{Percent: -1, Status: "Waiting for health checks"}, true},
+		{"::status", Progress{Percent: -1}, true},
+		{"::status   ", Progress{Percent: -1}, true},
+
+		// Not directives
+		{"::progress 1/0", Progress{}, false},
+		{"::progress 1/-2", Progress{}, false},
+		{"::progress NaN", Progress{}, false},
+		{"::progress nan%", Progress{}, false},
+		{"::progress Inf", Progress{}, false},
+		{"::progress -Infinity", Progress{}, false},
+		{"::progress inf/1", Progress{}, false},
+		{"::progress 1/inf", Progress{}, false},
+		{"::progress 1e400", Progress{}, false},
+		{"::progress", Progress{}, false},
+		{"::progress ", Progress{}, false},
+		{"::progress forty", Progress{}, false},
+		{"::progress 40%%", Progress{}, false},
+		{"::progress 3/", Progress{}, false},
+		{"::progress /8", Progress{}, false},
+		{"::progress 1/2/3", Progress{}, false},
+		{"::progressbar 40", Progress{}, false},
+		{"::statusline ok", Progress{}, false},
+		{"progress 40", Progress{}, false},
+		{"echo ::progress 40", Progress{}, false},
+		{"", Progress{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := Parse(tt.line)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("Parse(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
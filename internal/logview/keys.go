@@ -0,0 +1,86 @@
+package logview
+
+import "github.com/charmbracelet/bubbles/key"
+
+// KeyMap defines the keybindings of the log viewer. It satisfies
+// help.KeyMap so it can be rendered by the help bubble.
+type KeyMap struct {
+	Up         key.Binding
+	Down       key.Binding
+	PageUp     key.Binding
+	PageDown   key.Binding
+	Top        key.Binding
+	Bottom     key.Binding
+	Follow     key.Binding
+	Search     key.Binding
+	Next       key.Binding
+	Prev       key.Binding
+	Filter     key.Binding
+	Timestamps key.Binding
+}
+
+// ShortHelp returns keybindings to be shown in the mini help view.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Search, k.Next, k.Prev, k.Filter, k.Timestamps, k.Follow}
+}
+
+// FullHelp returns keybindings for the expanded help view.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown},
+		{k.Top, k.Bottom, k.Follow},
+		{k.Search, k.Next, k.Prev},
+		{k.Filter, k.Timestamps},
+	}
+}
+
+var Keys = KeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "scroll up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "scroll down"),
+	),
+	PageUp: key.NewBinding(
+		key.WithKeys("pgup", "b", "ctrl+u"),
+		key.WithHelp("pgup/b", "page up"),
+	),
+	PageDown: key.NewBinding(
+		key.WithKeys("pgdown", "ctrl+d"),
+		key.WithHelp("pgdn", "page down"),
+	),
+	Top: key.NewBinding(
+		key.WithKeys("home", "g"),
+		key.WithHelp("g", "to top"),
+	),
+	Bottom: key.NewBinding(
+		key.WithKeys("end", "G"),
+		key.WithHelp("G", "to bottom and follow"),
+	),
+	Follow: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "toggle follow"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	Next: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "next match"),
+	),
+	Prev: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "prev match"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "all/stderr/errors"),
+	),
+	Timestamps: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "timestamps"),
+	),
+}
@@ -0,0 +1,151 @@
+package logview
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Stream identifies which output stream a line was written to.
+type Stream byte
+
+const (
+	Stdout Stream = 'O'
+	Stderr Stream = 'E'
+)
+
+// Line is a single line of streamed output.
+type Line struct {
+	Time   time.Time
+	Stream Stream
+	Text   string
+}
+
+var errorWords = []string{"error", "fatal", "panic", "failed"}
+
+// IsError reports whether the line looks like it is reporting an error.
+func (l Line) IsError() bool {
+	t := strings.ToLower(l.Text)
+	for _, w := range errorWords {
+		if strings.Contains(t, w) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Filter restricts which lines are shown.
+type Filter int
+
+const (
+	All Filter = iota
+	StderrOnly
+	ErrorsOnly
+)
+
+func (f Filter) String() string {
+	switch f {
+	case StderrOnly:
+		return "stderr"
+	case ErrorsOnly:
+		return "errors"
+	default:
+		return "all"
+	}
+}
+
+func (f Filter) keep(l Line) bool {
+	switch f {
+	case StderrOnly:
+		return l.Stream == Stderr
+	case ErrorsOnly:
+		return l.IsError()
+	default:
+		return true
+	}
+}
+
+// Saved logs keep one line per entry as "<RFC3339 time> <O|E> <text>" so they
+// can be loaded back with their stream and timestamps intact.
+
+func encode(sb *strings.Builder, l Line) {
+	sb.WriteString(l.Time.Format(time.RFC3339Nano))
+	sb.WriteByte(' ')
+	sb.WriteByte(byte(l.Stream))
+	sb.WriteByte(' ')
+	sb.WriteString(l.Text)
+	sb.WriteByte('\n')
+}
+
+func decode(s string) Line {
+	parts := strings.SplitN(s, " ", 3)
+	if len(parts) == 3 && len(parts[1]) == 1 {
+		t, err := time.Parse(time.RFC3339Nano, parts[0])
+		stream := Stream(parts[1][0])
+		if err == nil && (stream == Stdout || stream == Stderr) {
+			return Line{Time: t, Stream: stream, Text: parts[2]}
+		}
+	}
+
+	return Line{Stream: Stdout, Text: s}
+}
+
+// Load reads a log saved by Model.SaveTo back into lines. Lines that weren't
+// written by go-live are kept as plain stdout text.
+func Load(path string) ([]Line, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := []Line{}
+	err = ReadLines(f, func(line []byte, _ bool) bool {
+		lines = append(lines, decode(string(line)))
+		return true
+	})
+
+	return lines, err
+}
+
+// MaxLineBytes is where ReadLines cuts long lines short.
+const MaxLineBytes = 1024 * 1024
+
+// ReadLines calls fn with each line of r, without its line ending, until r
+// ends or fn returns false. A line longer than MaxLineBytes is cut short and
+// the rest of it skipped, with cut set, so a runaway line can't stop the
+// reading and leave a child process blocked on a full pipe. line is only
+// valid until fn returns.
+func ReadLines(r io.Reader, fn func(line []byte, cut bool) bool) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	line, cut := []byte{}, false
+
+	for {
+		chunk, more, err := br.ReadLine()
+		if err != nil {
+			if len(line) > 0 || cut {
+				fn(line, cut)
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if room := MaxLineBytes - len(line); len(chunk) > room {
+			chunk, cut = chunk[:room], true
+		}
+		line = append(line, chunk...)
+		if more {
+			continue
+		}
+
+		if !fn(line, cut) {
+			return nil
+		}
+		line, cut = line[:0], false
+	}
+}
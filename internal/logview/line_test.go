@@ -0,0 +1,56 @@
+package logview
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadLines(t *testing.T) {
+	long := strings.Repeat("a", MaxLineBytes)
+
+	tests := []struct {
+		name  string
+		input string
+		lines []string
+		cut   []bool
+	}{
+		{"lines", "one\ntwo\n", []string{"one", "two"}, []bool{false, false}},
+		{"crlf", "one\r\ntwo\r\n", []string{"one", "two"}, []bool{false, false}},
+		{"no trailing newline", "one\ntwo", []string{"one", "two"}, []bool{false, false}},
+		{"blank lines", "\n\nthree\n", []string{"", "", "three"}, []bool{false, false, false}},
+		{"empty", "", []string{}, []bool{}},
+		{"at the limit", long + "\nnext\n", []string{long, "next"}, []bool{false, false}},
+		{"over the limit", long + "bbb\nnext\n", []string{long, "next"}, []bool{true, false}},
+		{"over the limit at the end", long + long, []string{long}, []bool{true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, cut := []string{}, []bool{}
+			err := ReadLines(strings.NewReader(tt.input), func(line []byte, c bool) bool {
+				lines = append(lines, string(line))
+				cut = append(cut, c)
+				return true
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(lines, tt.lines) || !slices.Equal(cut, tt.cut) {
+				t.Errorf("got %d lines cut %v, want %d lines cut %v", len(lines), cut, len(tt.lines), tt.cut)
+			}
+		})
+	}
+}
+
+func TestReadLinesStops(t *testing.T) {
+	n := 0
+	err := ReadLines(strings.NewReader("one\ntwo\nthree\n"), func([]byte, bool) bool {
+		n++
+		return n < 2
+	})
+	if err != nil || n != 2 {
+		t.Errorf("read %d lines, err %v, want 2 and nil", n, err)
+	}
+}
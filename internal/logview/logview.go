@@ -0,0 +1,426 @@
+// Package logview implements a scrollable, searchable log pane for streamed
+// command output. Only the rows on screen are rendered, so it stays
+// responsive with hundreds of thousands of lines.
+package logview
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	stderrStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF9E9E"))
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6E81")).Bold(true)
+	timeStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	matchStyle  = lipgloss.NewStyle().Background(lipgloss.Color("#6A6094"))
+	activeStyle = lipgloss.NewStyle().Background(lipgloss.Color("#FF6E81")).Foreground(lipgloss.Color("#000000"))
+)
+
+// Model is a log pane. Lines are added with Append and, once SaveTo has been
+// called, are also written to a file.
+type Model struct {
+	KeyMap     KeyMap
+	viewport   viewport.Model
+	search     textinput.Model
+	searching  bool
+	query      string
+	lines      []Line
+	shown      []int // indexes into lines that pass the filter
+	matches    []int // indexes into shown whose text matches the query
+	match      int
+	offset     int
+	follow     bool
+	filter     Filter
+	timestamps bool
+	help       help.Model
+	file       *os.File
+	path       string
+	err        error
+}
+
+func New(width, height int) Model {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "search"
+
+	m := Model{
+		KeyMap:   Keys,
+		viewport: viewport.New(width, height),
+		search:   search,
+		follow:   true,
+		help:     help.New(),
+	}
+	m.SetSize(width, height)
+
+	return m
+}
+
+// SetSize sets the outer size of the pane, including the status and help
+// lines.
+func (m *Model) SetSize(width, height int) {
+	m.viewport.Width = width
+	m.viewport.Height = max(height-3, 1)
+	m.search.Width = max(width-2, 1)
+	m.help.Width = width
+	m.clampOffset()
+}
+
+// SaveTo appends every line added from now on to the file at path.
+func (m *Model) SaveTo(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	m.Close()
+	m.file = f
+	m.path = path
+
+	return nil
+}
+
+// Close stops saving lines to the log file.
+func (m *Model) Close() error {
+	if m.file == nil {
+		return nil
+	}
+
+	err := m.file.Close()
+	m.file = nil
+
+	return err
+}
+
+// Reset drops every line, keeping the filter, search and display settings.
+func (m *Model) Reset() {
+	m.lines = nil
+	m.shown = nil
+	m.matches = nil
+	m.match = 0
+	m.offset = 0
+	m.follow = true
+	m.err = nil
+}
+
+// ShowError puts err in the status line, for problems with the log the pane
+// can't see for itself.
+func (m *Model) ShowError(err error) {
+	m.err = err
+}
+
+// Append adds lines to the end of the log.
+func (m *Model) Append(lines ...Line) {
+	sb := strings.Builder{}
+	for _, l := range lines {
+		l.Text = strings.ReplaceAll(strings.TrimRight(l.Text, "\r\n"), "\t", "    ")
+		if m.file != nil {
+			encode(&sb, l)
+		}
+
+		m.lines = append(m.lines, l)
+		if !m.filter.keep(l) {
+			continue
+		}
+
+		m.shown = append(m.shown, len(m.lines)-1)
+		if m.matchesQuery(l) {
+			m.matches = append(m.matches, len(m.shown)-1)
+		}
+	}
+
+	if m.file != nil && sb.Len() > 0 {
+		if _, err := m.file.WriteString(sb.String()); err != nil {
+			m.err = err
+		}
+	}
+
+	if m.follow {
+		m.offset = m.maxOffset()
+	}
+}
+
+// Len returns the number of lines in the log.
+func (m Model) Len() int {
+	return len(m.lines)
+}
+
+// Lines returns every line in the log, regardless of the filter.
+func (m Model) Lines() []Line {
+	return m.lines
+}
+
+// Typing reports whether the search prompt is capturing keys.
+func (m Model) Typing() bool {
+	return m.searching
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if m.searching {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch msg.Type {
+			case tea.KeyEnter:
+				m.searching = false
+				m.search.Blur()
+				m.setQuery(m.search.Value())
+				return m, nil
+			case tea.KeyEsc:
+				m.searching = false
+				m.search.Blur()
+				m.search.SetValue(m.query)
+				return m, nil
+			}
+		}
+
+		var cmd tea.Cmd
+		m.search, cmd = m.search.Update(msg)
+		return m, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.KeyMap.Up):
+			m.scroll(-1)
+		case key.Matches(msg, m.KeyMap.Down):
+			m.scroll(1)
+		case key.Matches(msg, m.KeyMap.PageUp):
+			m.scroll(-m.viewport.Height)
+		case key.Matches(msg, m.KeyMap.PageDown):
+			m.scroll(m.viewport.Height)
+		case key.Matches(msg, m.KeyMap.Top):
+			m.scroll(-len(m.shown))
+		case key.Matches(msg, m.KeyMap.Bottom):
+			m.scroll(len(m.shown))
+		case key.Matches(msg, m.KeyMap.Follow):
+			m.follow = !m.follow
+			if m.follow {
+				m.offset = m.maxOffset()
+			}
+		case key.Matches(msg, m.KeyMap.Search):
+			m.searching = true
+			m.search.CursorEnd()
+			return m, m.search.Focus()
+		case key.Matches(msg, m.KeyMap.Next):
+			m.jump(1)
+		case key.Matches(msg, m.KeyMap.Prev):
+			m.jump(-1)
+		case key.Matches(msg, m.KeyMap.Filter):
+			m.setFilter((m.filter + 1) % 3)
+		case key.Matches(msg, m.KeyMap.Timestamps):
+			m.timestamps = !m.timestamps
+		}
+	}
+
+	return m, nil
+}
+
+func (m Model) View() string {
+	rows := make([]string, 0, m.viewport.Height)
+	end := min(m.offset+m.viewport.Height, len(m.shown))
+	current := -1
+	if len(m.matches) > 0 {
+		current = m.matches[m.match]
+	}
+
+	for r := m.offset; r < end; r++ {
+		rows = append(rows, m.renderRow(r, r == current))
+	}
+
+	if len(m.shown) == 0 {
+		rows = append(rows, statusStyle.Render("No output yet"))
+	}
+
+	vp := m.viewport
+	vp.SetContent(strings.Join(rows, "\n"))
+
+	bottom := m.help.View(m.KeyMap)
+	if m.searching {
+		bottom = m.search.View()
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, m.statusView(), vp.View(), bottom)
+}
+
+func (m Model) statusView() string {
+	s := []string{
+		fmt.Sprintf("%d/%d lines", len(m.shown), len(m.lines)),
+		"filter: " + m.filter.String(),
+	}
+
+	if m.follow {
+		s = append(s, "following")
+	}
+
+	if m.query != "" {
+		n := 0
+		if len(m.matches) > 0 {
+			n = m.match + 1
+		}
+		s = append(s, fmt.Sprintf("/%s %d/%d", m.query, n, len(m.matches)))
+	}
+
+	if m.path != "" {
+		s = append(s, m.path)
+	}
+
+	if m.err != nil {
+		s = append(s, errorStyle.Render(m.err.Error()))
+	}
+
+	return statusStyle.MaxWidth(m.viewport.Width).Render(strings.Join(s, " · "))
+}
+
+func (m Model) renderRow(r int, current bool) string {
+	l := m.lines[m.shown[r]]
+
+	style := lipgloss.NewStyle()
+	if l.IsError() {
+		style = errorStyle
+	} else if l.Stream == Stderr {
+		style = stderrStyle
+	}
+
+	text := l.Text
+	if m.viewport.Width > 0 && len(text) > m.viewport.Width {
+		// Nothing past the edge of the pane is visible, don't style it.
+		n := m.viewport.Width
+		for n > 0 && !utf8.RuneStart(text[n]) {
+			n--
+		}
+		text = text[:n]
+	}
+
+	hl := matchStyle
+	if current {
+		hl = activeStyle
+	}
+
+	row := m.highlight(text, style, hl)
+	if m.timestamps {
+		row = timeStyle.Render(l.Time.Format("15:04:05.000")) + " " + row
+	}
+
+	return lipgloss.NewStyle().MaxWidth(m.viewport.Width).Render(row)
+}
+
+// highlight renders text with base, marking every occurrence of the query
+// with hl.
+func (m Model) highlight(text string, base, hl lipgloss.Style) string {
+	lower := strings.ToLower(text)
+	q := strings.ToLower(m.query)
+	if q == "" || len(lower) != len(text) || !strings.Contains(lower, q) {
+		return base.Render(text)
+	}
+
+	sb := strings.Builder{}
+	for {
+		i := strings.Index(lower, q)
+		if i < 0 {
+			break
+		}
+
+		sb.WriteString(base.Render(text[:i]))
+		sb.WriteString(hl.Render(text[i : i+len(q)]))
+		text, lower = text[i+len(q):], lower[i+len(q):]
+	}
+	sb.WriteString(base.Render(text))
+
+	return sb.String()
+}
+
+func (m Model) matchesQuery(l Line) bool {
+	return m.query != "" && strings.Contains(strings.ToLower(l.Text), strings.ToLower(m.query))
+}
+
+func (m *Model) setFilter(f Filter) {
+	m.filter = f
+	m.shown = m.shown[:0]
+	for i, l := range m.lines {
+		if f.keep(l) {
+			m.shown = append(m.shown, i)
+		}
+	}
+
+	m.setQuery(m.query)
+	if m.follow {
+		m.offset = m.maxOffset()
+	}
+	m.clampOffset()
+}
+
+// setQuery recomputes the matches for q and moves to the first one at or
+// after the top of the pane.
+func (m *Model) setQuery(q string) {
+	m.query = q
+	m.matches = m.matches[:0]
+	m.match = 0
+	if q == "" {
+		return
+	}
+
+	for r, i := range m.shown {
+		if m.matchesQuery(m.lines[i]) {
+			m.matches = append(m.matches, r)
+		}
+	}
+
+	for i, r := range m.matches {
+		if r >= m.offset {
+			m.match = i
+			break
+		}
+	}
+
+	if len(m.matches) > 0 {
+		m.reveal(m.matches[m.match])
+	}
+}
+
+func (m *Model) jump(dir int) {
+	if len(m.matches) == 0 {
+		return
+	}
+
+	m.match = (m.match + dir + len(m.matches)) % len(m.matches)
+	m.reveal(m.matches[m.match])
+}
+
+// reveal scrolls so row r is in the middle of the pane, leaving follow mode.
+func (m *Model) reveal(r int) {
+	if r >= m.offset && r < m.offset+m.viewport.Height {
+		m.follow = m.offset == m.maxOffset()
+		return
+	}
+
+	m.offset = r - m.viewport.Height/2
+	m.clampOffset()
+	m.follow = false
+}
+
+func (m *Model) scroll(n int) {
+	m.offset += n
+	m.clampOffset()
+	m.follow = m.offset == m.maxOffset()
+}
+
+func (m Model) maxOffset() int {
+	return max(len(m.shown)-m.viewport.Height, 0)
+}
+
+func (m *Model) clampOffset() {
+	m.offset = min(max(m.offset, 0), m.maxOffset())
+}
@@ -0,0 +1,283 @@
+package plugin
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-live/internal/common"
+	"go-live/internal/logview"
+)
+
+var (
+	titleStyle = lipgloss.NewStyle().
+			PaddingTop(2).
+			MarginBottom(1).
+			Foreground(lipgloss.Color("#01FAC6")).
+			Bold(true)
+	textStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#EFEDFF"))
+	activeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF6E81")).
+			Bold(true)
+	promptStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#6A6094")).
+			Padding(0, 1)
+	footerStyle = lipgloss.NewStyle().
+			MarginTop(1).
+			Bold(true)
+)
+
+// PluginModel is the screen of a single plugin. The plugin is started by
+// Init and its output is streamed into a log pane.
+type PluginModel struct {
+	keys    common.Keymap
+	plugin  Plugin
+	proc    *process
+	log     logview.Model
+	prompt  *event
+	cursor  int
+	running bool
+	starts  *int // bumped by Init and stop, to spot starts the user has left
+	status  string
+	width   int
+	height  int
+}
+
+func NewModel(p Plugin) PluginModel {
+	return PluginModel{
+		keys:   common.Keys,
+		plugin: p,
+		starts: new(int),
+		log:    logview.New(80, 20),
+	}
+}
+
+func (m PluginModel) Init() tea.Cmd {
+	*m.starts++
+	return start(m.plugin, m.starts)
+}
+
+func (m PluginModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height - lipgloss.Height(m.headerView()) - lipgloss.Height(m.footerView())
+		m.log.SetSize(m.width, m.height)
+		return m, nil
+
+	case startedMsg:
+		if msg.starts != m.starts {
+			return m, nil
+		}
+		if msg.n != *m.starts {
+			// The user left before it got going
+			if msg.proc != nil {
+				msg.proc.stop()
+			}
+			return m, nil
+		}
+
+		m.log.Close()
+		m.log.Reset()
+		m.prompt = nil
+		if msg.err != nil {
+			m.status = "failed to start: " + msg.err.Error()
+			return m, nil
+		}
+
+		m.proc = msg.proc
+		m.running = true
+		m.status = "running"
+		dir, err := common.StateDir("logs")
+		if err == nil {
+			name := fmt.Sprintf("plugin-%s-%s.log", m.plugin.Name, time.Now().Format("20060102-150405"))
+			err = m.log.SaveTo(filepath.Join(dir, name))
+		}
+		if err != nil {
+			m.log.ShowError(fmt.Errorf("log not saved: %w", err))
+		}
+
+		return m, m.proc.next()
+
+	case eventMsg:
+		if msg.proc != m.proc {
+			return m, nil
+		}
+
+		m.handleEvent(msg.ev)
+		return m, m.proc.next()
+
+	case replyFailedMsg:
+		if msg.proc == m.proc {
+			m.log.Append(logview.Line{Time: time.Now(), Stream: logview.Stderr, Text: "go-live: " + msg.err.Error()})
+		}
+		return m, nil
+
+	case exitMsg:
+		if msg.proc != m.proc {
+			return m, nil
+		}
+
+		m.running = false
+		m.prompt = nil
+		m.status = "exited"
+		if msg.err != nil {
+			m.status = msg.err.Error()
+		}
+		m.log.Close()
+
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.prompt != nil {
+			return m.updatePrompt(msg)
+		}
+
+		if key.Matches(msg, m.keys.Back) && !m.log.Typing() {
+			m.stop()
+			return m, common.BackToRoot()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.log, cmd = m.log.Update(msg)
+
+	return m, cmd
+}
+
+func (m *PluginModel) handleEvent(ev event) {
+	switch ev.Type {
+	case "list", "confirm":
+		m.prompt = &ev
+		m.cursor = 0
+	default:
+		stream := logview.Stdout
+		if ev.Stream == "stderr" {
+			stream = logview.Stderr
+		}
+
+		text := ev.Text
+		if ev.Type != "output" {
+			text = fmt.Sprintf("unknown plugin message %q", ev.Type)
+			stream = logview.Stderr
+		}
+
+		for _, line := range strings.Split(text, "\n") {
+			m.log.Append(logview.Line{Time: time.Now(), Stream: stream, Text: line})
+		}
+	}
+}
+
+func (m PluginModel) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.prompt
+
+	if p.Type == "confirm" {
+		switch {
+		case msg.String() == "y":
+			m.prompt = nil
+			return m, m.proc.reply(event{Type: "confirmed", ID: p.ID, Value: true})
+		case msg.String() == "n", key.Matches(msg, m.keys.Back):
+			m.prompt = nil
+			return m, m.proc.reply(event{Type: "confirmed", ID: p.ID, Value: false})
+		}
+
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if m.cursor < len(p.Items)-1 {
+			m.cursor++
+		}
+	case key.Matches(msg, m.keys.Select):
+		if len(p.Items) == 0 {
+			break
+		}
+
+		i := m.cursor
+		m.prompt = nil
+		return m, m.proc.reply(event{Type: "selected", ID: p.ID, Index: &i, Value: p.Items[i]})
+	case key.Matches(msg, m.keys.Back):
+		m.prompt = nil
+		return m, m.proc.reply(event{Type: "cancelled", ID: p.ID})
+	}
+
+	return m, nil
+}
+
+func (m PluginModel) View() string {
+	if m.prompt == nil {
+		return lipgloss.JoinVertical(lipgloss.Top, m.headerView(), m.log.View(), m.footerView())
+	}
+
+	// Make room for the prompt under the log
+	prompt := promptStyle.Render(m.promptView())
+	log := m.log
+	log.SetSize(m.width, m.height-lipgloss.Height(prompt))
+
+	s := []string{m.headerView(), log.View(), prompt, m.footerView()}
+
+	return lipgloss.JoinVertical(lipgloss.Top, s...)
+}
+
+func (m PluginModel) promptView() string {
+	p := m.prompt
+
+	if p.Type == "confirm" {
+		return activeStyle.Render(p.Text) + textStyle.Render(" [y/n]")
+	}
+
+	s := []string{textStyle.Bold(true).Render(p.Title)}
+	for i, item := range p.Items {
+		if i == m.cursor {
+			s = append(s, activeStyle.Render("-> "+item))
+		} else {
+			s = append(s, textStyle.Render("   "+item))
+		}
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, s...)
+}
+
+func (m PluginModel) headerView() string {
+	return titleStyle.Render(fmt.Sprintf("%s (%s)", m.plugin.Name, m.status))
+}
+
+func (m PluginModel) footerView() string {
+	return footerStyle.Render("🡠 Esc to stop and go back")
+}
+
+// Typing reports whether the log pane's search prompt is capturing keys.
+func (m PluginModel) Typing() bool {
+	return m.log.Typing()
+}
+
+// Stop kills the plugin when go-live quits.
+func (m PluginModel) Stop() {
+	if m.proc != nil && m.running {
+		m.proc.stop()
+	}
+	m.log.Close()
+}
+
+func (m *PluginModel) stop() {
+	if m.proc != nil && m.running {
+		m.proc.stop()
+	}
+
+	*m.starts++
+	m.running = false
+	m.prompt = nil
+	m.status = "stopped"
+	m.log.Close()
+}
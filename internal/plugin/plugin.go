@@ -0,0 +1,77 @@
+// Package plugin runs external go-live-<name> executables found on PATH as
+// root menu entries, git-style.
+//
+// A plugin talks to go-live with one JSON object per line. On stdout it can
+// send:
+//
+//	{"type":"output","text":"building...","stream":"stdout"}
+//	{"type":"list","id":"env","title":"Pick an environment","items":["staging","production"]}
+//	{"type":"confirm","id":"go","text":"Deploy to production?"}
+//
+// Lines that aren't protocol messages, and everything on stderr, are shown as
+// plain output. Answers are written to the plugin's stdin:
+//
+//	{"type":"selected","id":"env","index":1,"value":"production"}
+//	{"type":"confirmed","id":"go","value":true}
+//	{"type":"cancelled","id":"env"}
+//
+// Plugins are started with GO_LIVE_PLUGIN_PROTOCOL=1 in their environment and
+// are stopped when the user leaves their screen.
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const prefix = "go-live-"
+
+// Plugin is an executable discovered on PATH.
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// Discover returns the plugins on PATH sorted by name. As with the shell,
+// the first directory on PATH wins when a name appears more than once.
+func Discover() []Plugin {
+	seen := map[string]bool{}
+	plugins := []Plugin{}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		// Like exec.LookPath, which refuses what they'd find, skip the
+		// current directory and anything relative to it
+		if !filepath.IsAbs(dir) {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, e := range entries {
+			name, ok := strings.CutPrefix(e.Name(), prefix)
+			if !ok || name == "" || seen[name] {
+				continue
+			}
+
+			path := filepath.Join(dir, e.Name())
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+				continue
+			}
+
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: path})
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+
+	return plugins
+}
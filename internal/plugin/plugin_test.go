@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are found by their executable bit")
+	}
+
+	bin, cwd := t.TempDir(), t.TempDir()
+	for _, f := range []struct {
+		path string
+		mode os.FileMode
+	}{
+		{filepath.Join(bin, "go-live-deploy"), 0o755},
+		{filepath.Join(bin, "go-live-notes"), 0o644},
+		{filepath.Join(bin, "go-live-"), 0o755},
+		{filepath.Join(cwd, "go-live-here"), 0o755},
+		{filepath.Join(cwd, "rel", "go-live-relative"), 0o755},
+	} {
+		os.MkdirAll(filepath.Dir(f.path), 0o755)
+		if err := os.WriteFile(f.path, []byte("#!/bin/sh\n"), f.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(cwd); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	t.Setenv("PATH", strings.Join([]string{"", ".", "rel", bin}, string(os.PathListSeparator)))
+
+	plugins := Discover()
+	if len(plugins) != 1 || plugins[0].Name != "deploy" || plugins[0].Path != filepath.Join(bin, "go-live-deploy") {
+		t.Errorf("Discover() = %+v, want only deploy from %s", plugins, bin)
+	}
+}
@@ -0,0 +1,191 @@
+package plugin
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go-live/internal/logview"
+)
+
+// event is a message in either direction of the protocol.
+type event struct {
+	Type   string   `json:"type"`
+	ID     string   `json:"id,omitempty"`
+	Text   string   `json:"text,omitempty"`
+	Stream string   `json:"stream,omitempty"`
+	Title  string   `json:"title,omitempty"`
+	Items  []string `json:"items,omitempty"`
+	Index  *int     `json:"index,omitempty"`
+	Value  any      `json:"value,omitempty"`
+}
+
+// process is a running plugin. Its events are read from stdout and stderr in
+// the background and handed to the model one at a time through events.
+type process struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	events chan tea.Msg
+	done   chan struct{}
+	once   sync.Once
+}
+
+// startedMsg is tagged with the model's start counter, so it reaches the
+// plugin screen that asked for it and only the latest start is kept.
+type startedMsg struct {
+	starts *int
+	n      int
+	proc   *process
+	err    error
+}
+
+type eventMsg struct {
+	proc *process
+	ev   event
+}
+
+// replyFailedMsg reports a reply that couldn't be written to the plugin.
+// Unlike an eventMsg it doesn't come from events, so no read follows it.
+type replyFailedMsg struct {
+	proc *process
+	err  error
+}
+
+type exitMsg struct {
+	proc *process
+	err  error
+}
+
+// Commands
+
+func start(p Plugin, starts *int) tea.Cmd {
+	n := *starts
+
+	return func() tea.Msg {
+		msg := startedMsg{starts: starts, n: n}
+
+		cmd := exec.Command(p.Path)
+		cmd.Env = append(os.Environ(), "GO_LIVE_PLUGIN_PROTOCOL=1")
+
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		stderr, err := cmd.StderrPipe()
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+
+		if err := cmd.Start(); err != nil {
+			msg.err = err
+			return msg
+		}
+
+		proc := &process{
+			cmd:    cmd,
+			stdin:  stdin,
+			events: make(chan tea.Msg, 256),
+			done:   make(chan struct{}),
+		}
+
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+		go proc.read(stdout, true, &wg)
+		go proc.read(stderr, false, &wg)
+		go func() {
+			wg.Wait()
+			proc.send(exitMsg{proc: proc, err: cmd.Wait()})
+			close(proc.events)
+		}()
+
+		msg.proc = proc
+		return msg
+	}
+}
+
+// read turns each line of r into an event. Protocol messages are only
+// accepted on stdout, anything else is shown as plain output.
+func (p *process) read(r io.Reader, protocol bool, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	stream := "stdout"
+	if !protocol {
+		stream = "stderr"
+	}
+
+	err := logview.ReadLines(r, func(line []byte, cut bool) bool {
+		ev := event{}
+		if cut || !protocol || json.Unmarshal(line, &ev) != nil || ev.Type == "" {
+			ev = event{Type: "output", Text: string(line), Stream: stream}
+		}
+
+		if !p.send(eventMsg{proc: p, ev: ev}) {
+			return false
+		}
+		if cut {
+			return p.send(eventMsg{proc: p, ev: warning("line over 1 MiB cut short")})
+		}
+		return true
+	})
+	if err != nil {
+		p.send(eventMsg{proc: p, ev: warning("reading " + stream + ": " + err.Error())})
+	}
+}
+
+// warning is an event for go-live's own remarks about the plugin's output.
+func warning(text string) event {
+	return event{Type: "output", Stream: "stderr", Text: "go-live: " + text}
+}
+
+// send queues msg for the model, giving up once the plugin has been stopped
+// and nobody is reading anymore.
+func (p *process) send(msg tea.Msg) bool {
+	select {
+	case p.events <- msg:
+		return true
+	case <-p.done:
+		return false
+	}
+}
+
+// next waits for the plugin's next event.
+func (p *process) next() tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-p.events
+		if !ok {
+			return nil
+		}
+
+		return msg
+	}
+}
+
+// reply sends ev to the plugin's stdin.
+func (p *process) reply(ev event) tea.Cmd {
+	return func() tea.Msg {
+		if err := json.NewEncoder(p.stdin).Encode(ev); err != nil {
+			return replyFailedMsg{proc: p, err: err}
+		}
+
+		return nil
+	}
+}
+
+func (p *process) stop() {
+	p.once.Do(func() {
+		close(p.done)
+		p.stdin.Close()
+		p.cmd.Process.Kill()
+	})
+}
@@ -2,6 +2,7 @@ package root
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -10,6 +11,7 @@ import (
 
 	"go-live/internal/common"
 	"go-live/internal/live"
+	"go-live/internal/plugin"
 	"go-live/internal/utils"
 )
 
@@ -42,38 +44,45 @@ var (
 			Bold(true)
 )
 
-type optID int
+const rootKey = "root"
 
-const (
-	idRoot optID = iota
-	idLive
-	idUtils
-)
+type choice struct {
+	label string
+	key   string
+}
 
 type RootModel struct {
 	keys    common.Keymap
-	state   optID
 	models  map[string]tea.Model
-	current optID
-	choices []string
+	current string
+	choices []choice
 	cursor  int
 	help    help.Model
 }
 
 func NewModel() RootModel {
-	return RootModel{
-		keys:  common.Keys,
-		state: idRoot,
+	m := RootModel{
+		keys:    common.Keys,
+		current: rootKey,
 		models: map[string]tea.Model{
 			"live":  live.NewModel(),
 			"utils": utils.NewModel(),
 		},
-		choices: []string{
-			"Go Live",
-			"Utils",
+		choices: []choice{
+			{"Go Live", "live"},
+			{"Utils", "utils"},
 		},
 		help: help.New(),
 	}
+
+	// External go-live-<name> executables on PATH get an entry of their own
+	for _, p := range plugin.Discover() {
+		k := "plugin:" + p.Name
+		m.models[k] = plugin.NewModel(p)
+		m.choices = append(m.choices, choice{p.Name, k})
+	}
+
+	return m
 }
 
 func (m RootModel) Init() tea.Cmd {
@@ -95,14 +104,42 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Cool, what was the actual key pressed?
 		switch {
 		case key.Matches(msg, m.keys.Quit):
-			return m, tea.Quit
+			if msg.Type == tea.KeyCtrlC || !m.typing() {
+				m.stop()
+				return m, tea.Quit
+			}
 		}
 	case common.BackToRootMsg:
-		m.current = idRoot
+		m.current = rootKey
+
+	case common.NotifyMsg:
+		// Frames are flushed from the renderer's own goroutine, but each in a
+		// single write to the same *os.File, which serialises the two, so
+		// this can't land in the middle of one
+		os.Stdout.WriteString(msg.Sequence())
+		return m, nil
+
+	case common.CopyMsg:
+		os.Stdout.WriteString(msg.Sequence())
+		return m, nil
+	}
+
+	// Keys are for the screen on show. Everything else goes to every model:
+	// they need the size before they're shown, and background work reporting
+	// back has to reach its model wherever the user is, or whatever it was
+	// chaining stops for good.
+	if _, ok := msg.(tea.KeyMsg); !ok {
+		for k, nm := range m.models {
+			nm, cmd := nm.Update(msg)
+			m.models[k] = nm
+			cmds = append(cmds, cmd)
+		}
+
+		return m, tea.Batch(cmds...)
 	}
 
 	switch m.current {
-	case idRoot:
+	case rootKey:
 		// log.Println("m.current == sRoot")
 		switch msg := msg.(type) {
 		// Is it a key press?
@@ -121,6 +158,7 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			case key.Matches(msg, m.keys.Select):
 				m = m.setCurrent()
+				cmds = append(cmds, m.currentModel().Init())
 
 			case key.Matches(msg, m.keys.Help):
 				m.help.ShowAll = !m.help.ShowAll
@@ -144,7 +182,7 @@ func (m RootModel) View() string {
 	s = append(s, titleStyle.Render("What would you like to do?"))
 
 	switch m.current {
-	case idRoot:
+	case rootKey:
 		for i, choice := range m.choices {
 			// Is the cursor pointing at this choice?
 			cursor := " " // no cursor
@@ -154,9 +192,9 @@ func (m RootModel) View() string {
 
 			// Render the row
 			if i == m.cursor {
-				s = append(s, activeStyle.Render(fmt.Sprintf("%s %s", cursor, choice)))
+				s = append(s, activeStyle.Render(fmt.Sprintf("%s %s", cursor, choice.label)))
 			} else {
-				s = append(s, textStyle.Render(fmt.Sprintf(" %s %s", cursor, choice)))
+				s = append(s, textStyle.Render(fmt.Sprintf(" %s %s", cursor, choice.label)))
 			}
 		}
 	default:
@@ -175,37 +213,35 @@ func (m RootModel) View() string {
 }
 
 func (m RootModel) setCurrent() RootModel {
-	switch m.cursor {
-	case 0:
-		m.current = idLive
-	case 1:
-		m.current = idUtils
-	default:
-		m.current = idRoot
+	m.current = rootKey
+	if m.cursor >= 0 && m.cursor < len(m.choices) {
+		m.current = m.choices[m.cursor].key
 	}
 
 	return m
 }
 
-func (m RootModel) currentKey() string {
-	switch m.current {
-	case idRoot:
-		return "root"
-	case idLive:
-		return "live"
-	case idUtils:
-		return "utils"
-	}
+// typing reports whether the current model is capturing text input.
+func (m RootModel) typing() bool {
+	t, ok := m.currentModel().(common.Typer)
+	return ok && t.Typing()
+}
 
-	return ""
+// stop stops whatever the models are running in the background.
+func (m RootModel) stop() {
+	for _, nm := range m.models {
+		if s, ok := nm.(common.Stopper); ok {
+			s.Stop()
+		}
+	}
 }
 
 func (m RootModel) currentModel() tea.Model {
-	return m.models[m.currentKey()]
+	return m.models[m.current]
 }
 
 func (m RootModel) setCurrentModel(cm tea.Model) RootModel {
-	m.models[m.currentKey()] = cm
+	m.models[m.current] = cm
 
 	return m
 }
@@ -0,0 +1,172 @@
+package root
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// driver runs a model the way tea.Program does, one message at a time, with
+// commands run in the background.
+type driver struct {
+	t    *testing.T
+	m    tea.Model
+	msgs chan tea.Msg
+}
+
+func newDriver(t *testing.T) *driver {
+	t.Setenv("PATH", "")
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	return &driver{t: t, m: NewModel(), msgs: make(chan tea.Msg, 64)}
+}
+
+func (d *driver) run(cmd tea.Cmd) {
+	if cmd != nil {
+		go func() { d.msgs <- cmd() }()
+	}
+}
+
+func (d *driver) send(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case nil:
+		return
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			d.run(cmd)
+		}
+		return
+	}
+
+	var cmd tea.Cmd
+	d.m, cmd = d.m.Update(msg)
+	d.run(cmd)
+}
+
+func (d *driver) press(keys ...tea.KeyType) {
+	for _, k := range keys {
+		d.send(tea.KeyMsg{Type: k})
+	}
+}
+
+func (d *driver) typeText(s string) {
+	d.send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+}
+
+// settle handles messages until none have come in for a while.
+func (d *driver) settle() {
+	for {
+		select {
+		case msg := <-d.msgs:
+			d.send(msg)
+		case <-time.After(500 * time.Millisecond):
+			return
+		}
+	}
+}
+
+// waitFor handles messages until the view shows want.
+func (d *driver) waitFor(want string) {
+	d.t.Helper()
+	d.wait(want, true)
+}
+
+// waitWhile handles messages until the view stops showing s.
+func (d *driver) waitWhile(s string) {
+	d.t.Helper()
+	d.wait(s, false)
+}
+
+func (d *driver) wait(s string, shown bool) {
+	d.t.Helper()
+
+	timeout := time.After(10 * time.Second)
+	for strings.Contains(d.m.View(), s) != shown {
+		select {
+		case msg := <-d.msgs:
+			d.send(msg)
+		case <-timeout:
+			d.t.Fatalf("timed out waiting for %q to be shown %v, the view is:\n%s", s, shown, d.m.View())
+		}
+	}
+}
+
+func writeCSV(t *testing.T, rows int) string {
+	t.Helper()
+
+	sb := strings.Builder{}
+	sb.WriteString("id,name\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&sb, "%d,row %d\n", i, rows-i)
+	}
+
+	path := filepath.Join(t.TempDir(), "big.csv")
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// openTable goes from the root menu to Utils and opens the Table tool.
+func (d *driver) openTable() {
+	d.press(tea.KeyDown, tea.KeyEnter, tea.KeyEnter)
+}
+
+// leave goes back from the Table tool to the root menu.
+func (d *driver) leave() {
+	d.press(tea.KeyEsc, tea.KeyEsc)
+	d.waitFor("What would you like to do?")
+}
+
+func TestIndexingCarriesOnAtRoot(t *testing.T) {
+	d := newDriver(t)
+	path := writeCSV(t, 300000)
+
+	d.openTable()
+	d.typeText(path)
+	d.press(tea.KeyEnter)
+	d.waitFor("Indexing...")
+
+	d.leave()
+	d.settle()
+
+	d.openTable()
+	d.waitFor("300000 rows, 2 columns")
+}
+
+func TestSortAndExportCarryOnAtRoot(t *testing.T) {
+	d := newDriver(t)
+	path := writeCSV(t, 300000)
+
+	d.openTable()
+	d.typeText(path)
+	d.press(tea.KeyEnter)
+	d.waitFor("300000 rows, 2 columns")
+
+	d.typeText("s")
+	d.waitFor("Sorting...")
+	d.leave()
+	d.settle()
+
+	d.openTable()
+	d.waitFor("▲")
+	d.waitWhile("Sorting...")
+
+	out := filepath.Join(t.TempDir(), "out.csv")
+	d.typeText("x")
+	d.typeText(out)
+	d.press(tea.KeyEnter)
+	d.waitFor("Exporting...")
+	d.leave()
+	d.settle()
+
+	d.openTable()
+	d.waitFor("Exported 300000 rows to " + out)
+}
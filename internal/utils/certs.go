@@ -0,0 +1,320 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	warnStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFD866"))
+	expiredStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6E81")).Bold(true)
+)
+
+// certTarget is what was found for one host:port or PEM file.
+type certTarget struct {
+	name   string
+	chain  []*x509.Certificate
+	verify error
+	err    error
+}
+
+type certsMsg struct {
+	run     int
+	targets []certTarget
+}
+
+// certsModel inspects the certificate chains served by hosts or stored in
+// PEM files and flags the ones close to expiry.
+type certsModel struct {
+	input    textinput.Model
+	focused  bool
+	checking bool
+	run      int // the latest check, earlier results are dropped
+	targets  []certTarget
+	checked  time.Time
+}
+
+func newCerts() certsModel {
+	input := textinput.New()
+	input.Prompt = "Hosts or PEM files: "
+	input.Placeholder = "example.com api.example.com:8443 ./cert.pem"
+	input.Width = 60
+
+	return certsModel{input: input}
+}
+
+func (m *certsModel) Focus() tea.Cmd {
+	m.focused = true
+
+	return m.input.Focus()
+}
+
+func (m *certsModel) Blur() {
+	m.focused = false
+	m.input.Blur()
+}
+
+func (m certsModel) Focused() bool {
+	return m.focused
+}
+
+func (m certsModel) Typing() bool {
+	return m.focused
+}
+
+func (m certsModel) Update(msg tea.Msg) (certsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case certsMsg:
+		if msg.run != m.run {
+			return m, nil
+		}
+
+		m.checking = false
+		m.targets = msg.targets
+		m.checked = time.Now()
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyEnter {
+			names := strings.FieldsFunc(m.input.Value(), func(r rune) bool {
+				return r == ',' || r == ' '
+			})
+			if len(names) == 0 {
+				return m, nil
+			}
+
+			m.checking = true
+			m.run++
+			return m, inspectCerts(m.run, names)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+
+	return m, cmd
+}
+
+func (m certsModel) View() string {
+	s := []string{m.input.View(), mutedStyle.Render("enter to check · esc to go back")}
+
+	if m.checking {
+		s = append(s, "", "Checking...")
+	}
+
+	if len(m.targets) > 0 {
+		s = append(s, "", mutedStyle.Render(fmt.Sprintf("%-28s %-28s %-24s %-11s %-10s %5s  %s",
+			"Target", "Subject", "Issuer", "Key", "Expires", "Days", "SANs")))
+	}
+
+	for _, t := range m.targets {
+		if t.err != nil {
+			s = append(s, fmt.Sprintf("%-28s %s", truncate(t.name, 28), errStyle.Render(t.err.Error())))
+			continue
+		}
+
+		for i, c := range t.chain {
+			name := truncate(t.name, 28)
+			if i > 0 {
+				name = strings.Repeat(" ", min(i, 4)) + "└ intermediate"
+				if isSelfSigned(c) {
+					name = strings.Repeat(" ", min(i, 4)) + "└ root"
+				}
+			}
+			s = append(s, certRow(name, c, m.checked))
+		}
+
+		if t.verify != nil {
+			s = append(s, "  "+warnStyle.Render("verify: "+t.verify.Error()))
+		}
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, s...)
+}
+
+func certRow(name string, c *x509.Certificate, now time.Time) string {
+	days := int(c.NotAfter.Sub(now).Hours() / 24)
+	expiry := fmt.Sprintf("%-10s %5d", c.NotAfter.Format("2006-01-02"), days)
+
+	switch {
+	case days < 7:
+		expiry = expiredStyle.Render(expiry)
+	case days < 30:
+		expiry = warnStyle.Render(expiry)
+	default:
+		expiry = upStyle.Render(expiry)
+	}
+
+	return fmt.Sprintf("%-28s %-28s %-24s %-11s %s  %s",
+		name,
+		truncate(certName(c.Subject), 28),
+		truncate(certName(c.Issuer), 24),
+		keyType(c),
+		expiry,
+		truncate(strings.Join(altNames(c), ","), 40),
+	)
+}
+
+// altNames lists every kind of subject alternative name a server cert is
+// likely to carry, not just the DNS ones.
+func altNames(c *x509.Certificate) []string {
+	names := slices.Clone(c.DNSNames)
+	for _, ip := range c.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, u := range c.URIs {
+		names = append(names, u.String())
+	}
+
+	return names
+}
+
+// certName prefers the common name, which many CAs leave empty.
+func certName(n pkix.Name) string {
+	if n.CommonName != "" {
+		return n.CommonName
+	}
+
+	if len(n.Organization) > 0 {
+		return n.Organization[0]
+	}
+
+	return n.String()
+}
+
+func keyType(c *x509.Certificate) string {
+	switch k := c.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + k.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return c.PublicKeyAlgorithm.String()
+	}
+}
+
+func isSelfSigned(c *x509.Certificate) bool {
+	return c.Subject.String() == c.Issuer.String() && c.CheckSignatureFrom(c) == nil
+}
+
+// Commands
+
+func inspectCerts(run int, names []string) tea.Cmd {
+	return func() tea.Msg {
+		targets := make([]certTarget, len(names))
+
+		wg := sync.WaitGroup{}
+		wg.Add(len(names))
+		for i, name := range names {
+			go func(i int, name string) {
+				defer wg.Done()
+				targets[i] = inspectCert(name)
+			}(i, name)
+		}
+		wg.Wait()
+
+		return certsMsg{run: run, targets: targets}
+	}
+}
+
+// inspectCert reads the chain from name, a PEM file if one exists at that
+// path, otherwise a host with an optional port that defaults to 443.
+func inspectCert(name string) certTarget {
+	t := certTarget{name: name}
+
+	if _, err := os.Stat(name); err == nil {
+		t.chain, t.err = readPEM(name)
+		if t.err == nil {
+			t.verify = verifyChain(t.chain, "")
+		}
+
+		return t
+	}
+
+	host, port, err := net.SplitHostPort(name)
+	if err != nil {
+		host, port = name, "443"
+	}
+
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, port), &tls.Config{
+		ServerName: host,
+		// Verification is done separately so broken chains can still be
+		// inspected.
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		t.err = err
+		return t
+	}
+	defer conn.Close()
+
+	t.chain = conn.ConnectionState().PeerCertificates
+	t.verify = verifyChain(t.chain, host)
+
+	return t
+}
+
+func readPEM(path string) ([]*x509.Certificate, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []*x509.Certificate{}
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, c)
+	}
+
+	if len(chain) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+
+	return chain, nil
+}
+
+func verifyChain(chain []*x509.Certificate, host string) error {
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Intermediates: intermediates,
+	})
+
+	return err
+}
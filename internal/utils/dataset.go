@@ -0,0 +1,550 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// dataset is a table of string cells held in memory, used for JSON array
+// files which can't be read a row at a time.
+type dataset struct {
+	header []string
+	rows   [][]string
+}
+
+type dataFormat int
+
+const (
+	formatCSV dataFormat = iota
+	formatTSV
+	formatJSONL
+	formatJSON
+)
+
+// formatFor picks a format from the file extension.
+func formatFor(path string) (dataFormat, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return formatCSV, true
+	case ".tsv", ".tab":
+		return formatTSV, true
+	case ".jsonl", ".ndjson":
+		return formatJSONL, true
+	case ".json":
+		return formatJSON, true
+	}
+
+	return formatCSV, false
+}
+
+// sniffFormat guesses the format of a file without a known extension from
+// its first line.
+func sniffFormat(first []byte) dataFormat {
+	switch {
+	case bytes.HasPrefix(bytes.TrimSpace(first), []byte("{")):
+		return formatJSONL
+	case bytes.Contains(first, []byte("\t")):
+		return formatTSV
+	default:
+		return formatCSV
+	}
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+
+	return path
+}
+
+// loadDataset reads a JSON file holding an array of objects. The columns are
+// the object keys in the order first seen.
+func loadDataset(path string) (dataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return dataset{}, err
+	}
+	defer f.Close()
+
+	d := dataset{}
+	cols := map[string]int{}
+	dec := json.NewDecoder(bufio.NewReader(f))
+
+	if tok, err := dec.Token(); err != nil {
+		return dataset{}, err
+	} else if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return dataset{}, fmt.Errorf("expected a JSON array, got %v", tok)
+	}
+
+	rows := []map[string]string{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return dataset{}, err
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+			return dataset{}, fmt.Errorf("expected a JSON object, got %v", tok)
+		}
+
+		row := map[string]string{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return dataset{}, err
+			}
+			k := tok.(string)
+
+			raw := json.RawMessage{}
+			if err := dec.Decode(&raw); err != nil {
+				return dataset{}, err
+			}
+
+			if _, ok := cols[k]; !ok {
+				cols[k] = len(d.header)
+				d.header = append(d.header, k)
+			}
+			row[k] = jsonCell(raw)
+		}
+
+		// Closing brace
+		if _, err := dec.Token(); err != nil {
+			return dataset{}, err
+		}
+		rows = append(rows, row)
+	}
+
+	if len(d.header) == 0 {
+		return dataset{}, errors.New("no JSON objects found")
+	}
+
+	d.rows = make([][]string, len(rows))
+	for i, row := range rows {
+		d.rows[i] = make([]string, len(d.header))
+		for k, v := range row {
+			d.rows[i][cols[k]] = v
+		}
+	}
+
+	return d, nil
+}
+
+// jsonCell shows strings without their quotes and anything else as JSON.
+func jsonCell(raw json.RawMessage) string {
+	s := ""
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	if string(raw) == "null" {
+		return ""
+	}
+
+	return string(raw)
+}
+
+func fit(row []string, n int) []string {
+	if len(row) >= n {
+		return row[:n]
+	}
+
+	return append(row, make([]string, n-len(row))...)
+}
+
+// widths sizes each column to its longest value, looking at up to the first
+// 1000 rows and capping at limit. Headers get room for the sort and column
+// markers.
+func widths(src dataSource, limit int) []int {
+	header := src.Header()
+	w := make([]int, len(header))
+	for i, h := range header {
+		w[i] = min(lipgloss.Width(h)+4, limit)
+	}
+
+	for r := 0; r < min(src.Len(), 1000); r++ {
+		row, err := src.Row(r)
+		if err != nil {
+			break
+		}
+		for i, c := range row {
+			w[i] = min(max(w[i], lipgloss.Width(c)), limit)
+		}
+	}
+
+	return w
+}
+
+// column returns the index of the column called name, ignoring case.
+func column(header []string, name string) int {
+	for i, h := range header {
+		if strings.EqualFold(h, name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+var numberJunk = strings.NewReplacer(",", "", "_", "", "%", "")
+
+// number parses cells like "37,274,000", "1_000" or "12.5%".
+func number(s string) (float64, bool) {
+	s = numberJunk.Replace(strings.TrimSpace(s))
+	if s == "" {
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+
+	return f, err == nil
+}
+
+// compareCells orders numbers numerically and before anything else, the rest
+// case-insensitively.
+func compareCells(a, b string) int {
+	na, aok := number(a)
+	nb, bok := number(b)
+
+	switch {
+	case aok && bok:
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+		return 0
+	case aok:
+		return -1
+	case bok:
+		return 1
+	}
+
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// sortKey is a cell parsed once up front, so sorting a large file doesn't
+// parse numbers on every comparison.
+type sortKey struct {
+	num   float64
+	isNum bool
+	str   string
+}
+
+func (a sortKey) compare(b sortKey) int {
+	switch {
+	case a.isNum && b.isNum:
+		switch {
+		case a.num < b.num:
+			return -1
+		case a.num > b.num:
+			return 1
+		}
+		return 0
+	case a.isNum:
+		return -1
+	case b.isNum:
+		return 1
+	}
+
+	return strings.Compare(a.str, b.str)
+}
+
+// sortRows sorts idx, row positions in src, by column col. It reads the
+// column in one pass and keeps only its keys in memory.
+func sortRows(src dataSource, idx []int, col int, desc bool) error {
+	keys := make([]sortKey, src.Len())
+	err := src.Scan(func(i int, row []string) bool {
+		k := sortKey{}
+		if k.num, k.isNum = number(row[col]); !k.isNum {
+			k.str = strings.ToLower(row[col])
+		}
+		keys[i] = k
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(idx, func(a, b int) int {
+		if desc {
+			return keys[b].compare(keys[a])
+		}
+		return keys[a].compare(keys[b])
+	})
+
+	return nil
+}
+
+// filterRows returns the positions of the rows matching terms.
+func filterRows(src dataSource, terms []filterTerm) ([]int, error) {
+	idx := []int{}
+	err := src.Scan(func(i int, row []string) bool {
+		if matchAll(terms, row) {
+			idx = append(idx, i)
+		}
+		return true
+	})
+
+	return idx, err
+}
+
+// Filters are space separated terms that must all match. A bare term matches
+// any cell containing it, column terms compare a single column:
+//
+//	tokyo  country=japan  population>10,000,000  city~san  rank!=1
+
+type filterTerm struct {
+	col   int // -1 for any column
+	op    string
+	value string
+	num   float64
+	isNum bool
+}
+
+var filterOps = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+func parseFilter(header []string, q string) ([]filterTerm, error) {
+	terms := []filterTerm{}
+
+	for _, f := range strings.Fields(q) {
+		t := filterTerm{col: -1, op: "~", value: strings.ToLower(f)}
+
+		for _, op := range filterOps {
+			name, value, ok := strings.Cut(f, op)
+			if !ok || name == "" {
+				continue
+			}
+
+			t.col = column(header, name)
+			if t.col < 0 {
+				return nil, fmt.Errorf("no column named %q", name)
+			}
+			t.op, t.value = op, strings.ToLower(value)
+			t.num, t.isNum = number(value)
+			break
+		}
+
+		terms = append(terms, t)
+	}
+
+	return terms, nil
+}
+
+func (t filterTerm) match(row []string) bool {
+	if t.col < 0 {
+		for _, c := range row {
+			if strings.Contains(strings.ToLower(c), t.value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	cell := row[t.col]
+	switch t.op {
+	case "~":
+		return strings.Contains(strings.ToLower(cell), t.value)
+	case "=":
+		return strings.EqualFold(cell, t.value) || (t.isNum && compareCells(cell, t.value) == 0)
+	case "!=":
+		return !strings.EqualFold(cell, t.value) && !(t.isNum && compareCells(cell, t.value) == 0)
+	}
+
+	n, ok := number(cell)
+	if !ok || !t.isNum {
+		return false
+	}
+
+	switch t.op {
+	case ">":
+		return n > t.num
+	case ">=":
+		return n >= t.num
+	case "<":
+		return n < t.num
+	case "<=":
+		return n <= t.num
+	}
+
+	return false
+}
+
+func matchAll(terms []filterTerm, row []string) bool {
+	for _, t := range terms {
+		if !t.match(row) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// export writes the rows at idx, or every row when idx is nil, to path, as
+// JSON for .json and .jsonl and as CSV or TSV otherwise. Rows are written to
+// a temporary file that replaces path once it's complete, as path may well be
+// the file the rows are still being read from.
+func export(src dataSource, path string, idx []int) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := writeRows(f, src, path, idx); err != nil {
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// writeRows writes the export to f in the format path's extension asks for.
+func writeRows(f *os.File, src dataSource, path string, idx []int) error {
+	w := bufio.NewWriter(f)
+	format, _ := formatFor(path)
+	header := src.Header()
+
+	var cw *csv.Writer
+	switch format {
+	case formatJSON:
+		w.WriteString("[\n")
+	case formatJSONL:
+	default:
+		cw = csv.NewWriter(w)
+		if format == formatTSV {
+			cw.Comma = '\t'
+		}
+		cw.Write(header)
+	}
+
+	n, total := 0, src.Len()
+	if idx != nil {
+		total = len(idx)
+	}
+
+	err := eachRow(src, idx, func(row []string) error {
+		n++
+		if cw != nil {
+			return cw.Write(row)
+		}
+
+		if err := writeObject(w, header, row); err != nil {
+			return err
+		}
+		if format == formatJSON && n < total {
+			w.WriteByte(',')
+		}
+		return w.WriteByte('\n')
+	})
+	if err != nil {
+		return err
+	}
+
+	if cw != nil {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	} else if format == formatJSON {
+		w.WriteString("]\n")
+	}
+
+	return w.Flush()
+}
+
+// exportBlock is how many rows of a sorted view are gathered per pass over
+// the file.
+const exportBlock = 65536
+
+// eachRow calls fn with the rows at idx in that order, or every row when idx
+// is nil. Rows are collected a block at a time with a pass over the source,
+// rather than read one by one in sorted order.
+func eachRow(src dataSource, idx []int, fn func(row []string) error) error {
+	if idx == nil {
+		var ferr error
+		err := src.Scan(func(_ int, row []string) bool {
+			ferr = fn(row)
+			return ferr == nil
+		})
+		if ferr != nil {
+			return ferr
+		}
+		return err
+	}
+
+	for len(idx) > 0 {
+		block := idx[:min(len(idx), exportBlock)]
+		idx = idx[len(block):]
+
+		want := make(map[int][]string, len(block))
+		for _, i := range block {
+			want[i] = nil
+		}
+
+		err := src.Scan(func(i int, row []string) bool {
+			if _, ok := want[i]; ok {
+				want[i] = append(make([]string, 0, len(row)), row...)
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, i := range block {
+			if want[i] == nil {
+				return fmt.Errorf("row %d is past the end of the data", i+1)
+			}
+			if err := fn(want[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeObject writes row as a JSON object, keeping the column order.
+func writeObject(w *bufio.Writer, header, row []string) error {
+	w.WriteByte('{')
+	for i, h := range header {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+
+		k, err := json.Marshal(h)
+		if err != nil {
+			return err
+		}
+		v, err := json.Marshal(row[i])
+		if err != nil {
+			return err
+		}
+
+		w.Write(k)
+		w.WriteByte(':')
+		w.Write(v)
+	}
+	w.WriteByte('}')
+
+	return nil
+}
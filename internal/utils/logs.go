@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-live/internal/common"
+	"go-live/internal/logview"
+)
+
+var errStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6E81"))
+
+type logFilesMsg struct {
+	dir  string
+	rows []table.Row
+	err  error
+}
+
+type logLoadedMsg struct {
+	path  string
+	lines []logview.Line
+	err   error
+}
+
+// logsModel lists the saved logs and opens them in a log viewer.
+type logsModel struct {
+	keys    common.Keymap
+	files   table.Model
+	viewer  logview.Model
+	dir     string
+	viewing bool
+	focused bool
+	err     error
+}
+
+func newLogs() logsModel {
+	files := table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Log", Width: 40},
+			{Title: "Modified", Width: 19},
+			{Title: "Size", Width: 10},
+		}),
+		table.WithHeight(10),
+		table.WithStyles(tableStyles()),
+	)
+
+	return logsModel{
+		keys:   common.Keys,
+		files:  files,
+		viewer: logview.New(80, 20),
+	}
+}
+
+func (m *logsModel) Focus() tea.Cmd {
+	m.focused = true
+	m.files.Focus()
+
+	return listLogs()
+}
+
+func (m *logsModel) Blur() {
+	m.focused = false
+	m.viewing = false
+	m.files.Blur()
+}
+
+func (m logsModel) Focused() bool {
+	return m.focused
+}
+
+func (m logsModel) Typing() bool {
+	return m.viewing && m.viewer.Typing()
+}
+
+func (m *logsModel) SetSize(width, height int) {
+	m.files.SetHeight(max(height-4, 3))
+	m.viewer.SetSize(width, height)
+}
+
+func (m logsModel) Update(msg tea.Msg) (logsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case logFilesMsg:
+		m.dir, m.err = msg.dir, msg.err
+		m.files.SetRows(msg.rows)
+		return m, nil
+
+	case logLoadedMsg:
+		m.err = msg.err
+		m.viewer.Reset()
+		m.viewer.Append(msg.lines...)
+		m.viewing = msg.err == nil
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.viewing {
+			if key.Matches(msg, m.keys.Back) && !m.viewer.Typing() {
+				m.viewing = false
+				return m, nil
+			}
+
+			var cmd tea.Cmd
+			m.viewer, cmd = m.viewer.Update(msg)
+			return m, cmd
+		}
+
+		if key.Matches(msg, m.keys.Select) && len(m.files.Rows()) > 0 {
+			return m, loadLog(filepath.Join(m.dir, m.files.SelectedRow()[0]))
+		}
+	}
+
+	var cmd tea.Cmd
+	m.files, cmd = m.files.Update(msg)
+
+	return m, cmd
+}
+
+func (m logsModel) View() string {
+	if m.viewing {
+		return m.viewer.View()
+	}
+
+	s := []string{}
+	if m.err != nil {
+		s = append(s, errStyle.Render(m.err.Error()))
+	}
+
+	if len(m.files.Rows()) == 0 {
+		s = append(s, "No saved logs yet in "+m.dir)
+	} else {
+		s = append(s, tableStyle.Render(m.files.View()))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, s...)
+}
+
+// Commands
+
+func listLogs() tea.Cmd {
+	return func() tea.Msg {
+		dir, err := common.StateDir("logs")
+		if err != nil {
+			return logFilesMsg{err: err}
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return logFilesMsg{dir: dir, err: err}
+		}
+
+		infos := []os.FileInfo{}
+		for _, e := range entries {
+			if info, err := e.Info(); err == nil && !e.IsDir() {
+				infos = append(infos, info)
+			}
+		}
+
+		// Newest first
+		sort.Slice(infos, func(i, j int) bool {
+			return infos[i].ModTime().After(infos[j].ModTime())
+		})
+
+		rows := make([]table.Row, 0, len(infos))
+		for _, info := range infos {
+			rows = append(rows, table.Row{
+				info.Name(),
+				info.ModTime().Format("2006-01-02 15:04:05"),
+				humanBytes(info.Size()),
+			})
+		}
+
+		return logFilesMsg{dir: dir, rows: rows}
+	}
+}
+
+func loadLog(path string) tea.Cmd {
+	return func() tea.Msg {
+		lines, err := logview.Load(path)
+		return logLoadedMsg{path: path, lines: lines, err: err}
+	}
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for n/div >= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
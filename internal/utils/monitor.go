@@ -0,0 +1,532 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-live/internal/common"
+)
+
+const monitorExample = `{
+  "webhook": "https://hooks.example.com/uptime",
+  "endpoints": [
+    {"name": "api", "kind": "http", "target": "https://api.example.com/healthz", "interval": "30s"},
+    {"name": "db", "kind": "tcp", "target": "db.internal:5432", "interval": "10s"},
+    {"name": "dns", "kind": "dns", "target": "example.com", "interval": "1m"}
+  ]
+}`
+
+var (
+	upStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#00C57A"))
+	downStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6E81")).Bold(true)
+	sparkStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#6A6094"))
+	sparks     = []rune("▁▂▃▄▅▆▇█")
+)
+
+type endpointConfig struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"` // http, tcp or dns
+	Target   string `json:"target"`
+	Interval string `json:"interval"`
+	Timeout  string `json:"timeout"`
+	Webhook  string `json:"webhook"`
+}
+
+type monitorConfig struct {
+	Webhook   string           `json:"webhook"`
+	Endpoints []endpointConfig `json:"endpoints"`
+}
+
+type endpointStatus int
+
+const (
+	statusPending endpointStatus = iota
+	statusUp
+	statusDown
+)
+
+func (s endpointStatus) String() string {
+	switch s {
+	case statusUp:
+		return "up"
+	case statusDown:
+		return "down"
+	default:
+		return "pending"
+	}
+}
+
+// endpointState is what the monitor knows about an endpoint so far.
+type endpointState struct {
+	config    endpointConfig
+	status    endpointStatus
+	latency   time.Duration
+	err       error
+	checks    int
+	ups       int
+	latencies []time.Duration // most recent last
+}
+
+type monitorEvent struct {
+	time    time.Time
+	message string
+	down    bool
+}
+
+// monitor polls endpoints in the background, independently of the UI, so
+// checks and webhooks keep going while other screens are open.
+type monitor struct {
+	mu          sync.Mutex
+	endpoints   []*endpointState
+	events      []monitorEvent
+	transitions chan monitorEvent // for notifications, dropped when full
+	cancel      context.CancelFunc
+}
+
+type monitorTickMsg struct {
+	id int
+}
+
+type monitorEventMsg struct {
+	event monitorEvent
+}
+
+type monitorLoadedMsg struct {
+	path   string
+	config monitorConfig
+	err    error
+}
+
+// monitorModel shows a live grid of the monitored endpoints.
+type monitorModel struct {
+	keys     common.Keymap
+	reload   key.Binding
+	mon      *monitor
+	path     string
+	watching bool
+	focused  bool
+	tick     int
+	err      error
+}
+
+func newMonitor() monitorModel {
+	return monitorModel{
+		keys: common.Keys,
+		reload: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "reload config"),
+		),
+		mon: &monitor{transitions: make(chan monitorEvent, 16)},
+	}
+}
+
+func (m *monitorModel) Focus() tea.Cmd {
+	m.focused = true
+	m.tick++
+
+	if m.path == "" {
+		return tea.Batch(loadMonitorConfig(), monitorTick(m.tick))
+	}
+
+	return monitorTick(m.tick)
+}
+
+func (m *monitorModel) Blur() {
+	m.focused = false
+}
+
+func (m monitorModel) Focused() bool {
+	return m.focused
+}
+
+func (m monitorModel) Update(msg tea.Msg) (monitorModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case monitorLoadedMsg:
+		m.path, m.err = msg.path, msg.err
+		if msg.err == nil {
+			m.err = m.mon.start(msg.config)
+		}
+		if m.err != nil || m.watching {
+			return m, nil
+		}
+
+		m.watching = true
+		return m, waitForTransition(m.mon)
+
+	case monitorEventMsg:
+		// Sent as a desktop notification, whatever is on screen
+		body := msg.event.message
+		return m, tea.Batch(
+			func() tea.Msg { return common.NotifyMsg{Title: "go-live", Body: body} },
+			waitForTransition(m.mon),
+		)
+
+	case monitorTickMsg:
+		// Only keep redrawing while the grid is on screen, polling carries on
+		// regardless.
+		if !m.focused || msg.id != m.tick {
+			return m, nil
+		}
+		return m, monitorTick(m.tick)
+
+	case tea.KeyMsg:
+		if key.Matches(msg, m.reload) {
+			return m, loadMonitorConfig()
+		}
+	}
+
+	return m, nil
+}
+
+func (m monitorModel) View() string {
+	s := []string{}
+
+	if m.err != nil {
+		s = append(s, errStyle.Render(m.err.Error()))
+	}
+
+	m.mon.mu.Lock()
+	defer m.mon.mu.Unlock()
+
+	if len(m.mon.endpoints) == 0 {
+		s = append(s,
+			"No endpoints configured yet, add them to "+m.path,
+			"",
+			mutedStyle.Render(monitorExample),
+		)
+	} else {
+		s = append(s, mutedStyle.Render(fmt.Sprintf("%-16s %-5s %-32s %-8s %9s  %-20s %7s", "Name", "Kind", "Target", "Status", "Latency", "Recent", "Uptime")))
+		for _, e := range m.mon.endpoints {
+			s = append(s, endpointRow(e))
+		}
+	}
+
+	if len(m.mon.events) > 0 {
+		s = append(s, "", titleStyle.MarginBottom(0).Render("Events"))
+		for i := len(m.mon.events) - 1; i >= 0 && i >= len(m.mon.events)-5; i-- {
+			ev := m.mon.events[i]
+			style := upStyle
+			if ev.down {
+				style = downStyle
+			}
+			s = append(s, mutedStyle.Render(ev.time.Format("15:04:05"))+" "+style.Render(ev.message))
+		}
+	}
+
+	s = append(s, "", mutedStyle.Render("r to reload config · esc to go back"))
+
+	return lipgloss.JoinVertical(lipgloss.Left, s...)
+}
+
+// Notification returns the latest state transition, if any, so it can be
+// surfaced outside of the grid.
+func (m monitorModel) Notification() (string, bool) {
+	m.mon.mu.Lock()
+	defer m.mon.mu.Unlock()
+
+	if len(m.mon.events) == 0 {
+		return "", false
+	}
+
+	ev := m.mon.events[len(m.mon.events)-1]
+	style := upStyle
+	if ev.down {
+		style = downStyle
+	}
+
+	return style.Render(fmt.Sprintf("%s %s", ev.time.Format("15:04:05"), ev.message)), true
+}
+
+func endpointRow(e *endpointState) string {
+	status := mutedStyle.Render(fmt.Sprintf("%-8s", e.status))
+	switch e.status {
+	case statusUp:
+		status = upStyle.Render(fmt.Sprintf("%-8s", e.status))
+	case statusDown:
+		status = downStyle.Render(fmt.Sprintf("%-8s", e.status))
+	}
+
+	uptime := "-"
+	if e.checks > 0 {
+		uptime = fmt.Sprintf("%.1f%%", float64(e.ups)*100/float64(e.checks))
+	}
+
+	return fmt.Sprintf("%-16s %-5s %-32s %s %9s  %s %7s",
+		truncate(e.config.Name, 16),
+		e.config.Kind,
+		truncate(e.config.Target, 32),
+		status,
+		ms(e.latency),
+		sparkStyle.Render(fmt.Sprintf("%-20s", sparkline(e.latencies))),
+		uptime,
+	)
+}
+
+// sparkline scales latencies between the fastest and slowest of them.
+func sparkline(latencies []time.Duration) string {
+	if len(latencies) == 0 {
+		return ""
+	}
+
+	lo, hi := latencies[0], latencies[0]
+	for _, d := range latencies {
+		lo, hi = min(lo, d), max(hi, d)
+	}
+
+	s := make([]rune, 0, len(latencies))
+	for _, d := range latencies {
+		i := 0
+		if hi > lo {
+			i = int((d - lo) * time.Duration(len(sparks)-1) / (hi - lo))
+		}
+		s = append(s, sparks[i])
+	}
+
+	return string(s)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n-1]) + "…"
+}
+
+// start stops any running checks and starts polling the endpoints in config.
+func (mon *monitor) start(config monitorConfig) error {
+	endpoints := make([]*endpointState, 0, len(config.Endpoints))
+	for _, c := range config.Endpoints {
+		if c.Webhook == "" {
+			c.Webhook = config.Webhook
+		}
+
+		switch c.Kind {
+		case "http", "tcp", "dns":
+		default:
+			return fmt.Errorf("endpoint %q: unknown kind %q, want http, tcp or dns", c.Name, c.Kind)
+		}
+
+		endpoints = append(endpoints, &endpointState{config: c})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mon.mu.Lock()
+	if mon.cancel != nil {
+		mon.cancel()
+	}
+	mon.endpoints = endpoints
+	mon.cancel = cancel
+	mon.mu.Unlock()
+
+	for _, e := range endpoints {
+		go mon.poll(ctx, e)
+	}
+
+	return nil
+}
+
+func (mon *monitor) poll(ctx context.Context, e *endpointState) {
+	interval := parseDuration(e.config.Interval, 30*time.Second)
+	timeout := parseDuration(e.config.Timeout, min(interval, 10*time.Second))
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		latency, err := check(ctx, e.config, timeout)
+		if ctx.Err() != nil {
+			return
+		}
+
+		mon.record(e, latency, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// record stores the outcome of a check and reports status changes.
+func (mon *monitor) record(e *endpointState, latency time.Duration, err error) {
+	mon.mu.Lock()
+	defer mon.mu.Unlock()
+
+	prev := e.status
+	e.checks++
+	e.latency = latency
+	e.err = err
+	e.status = statusDown
+	if err == nil {
+		e.status = statusUp
+		e.ups++
+	}
+
+	e.latencies = append(e.latencies, latency)
+	if len(e.latencies) > 20 {
+		e.latencies = e.latencies[1:]
+	}
+
+	if prev == e.status || (prev == statusPending && e.status == statusUp) {
+		return
+	}
+
+	msg := fmt.Sprintf("%s is %s", e.config.Name, e.status)
+	if err != nil {
+		msg += ": " + err.Error()
+	}
+
+	ev := monitorEvent{time: time.Now(), message: msg, down: err != nil}
+	mon.events = append(mon.events, ev)
+	if len(mon.events) > 100 {
+		mon.events = mon.events[1:]
+	}
+
+	select {
+	case mon.transitions <- ev:
+	default:
+	}
+
+	if e.config.Webhook != "" {
+		go notify(e.config.Webhook, *e, prev)
+	}
+}
+
+func check(ctx context.Context, c endpointConfig, timeout time.Duration) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	switch c.Kind {
+	case "http":
+		res := get(ctx, http.DefaultClient, c.Target)
+		err := res.err
+		if err == nil && res.status >= 400 {
+			err = fmt.Errorf("status %d", res.status)
+		}
+		return time.Since(start), err
+
+	case "tcp":
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", c.Target)
+		if err == nil {
+			conn.Close()
+		}
+		return time.Since(start), err
+
+	default:
+		addrs, err := net.DefaultResolver.LookupHost(ctx, c.Target)
+		if err == nil && len(addrs) == 0 {
+			err = errors.New("no addresses")
+		}
+		return time.Since(start), err
+	}
+}
+
+// notify posts a state transition to a webhook. Failures are ignored, the
+// transition is already shown in the grid.
+func notify(url string, e endpointState, prev endpointStatus) {
+	body := map[string]any{
+		"name":       e.config.Name,
+		"kind":       e.config.Kind,
+		"target":     e.config.Target,
+		"status":     e.status.String(),
+		"previous":   prev.String(),
+		"latency_ms": e.latency.Milliseconds(),
+		"time":       time.Now().Format(time.RFC3339),
+	}
+	if e.err != nil {
+		body["error"] = e.err.Error()
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+
+	c := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	res, err := c.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return
+	}
+	res.Body.Close()
+}
+
+func parseDuration(s string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+
+	return d
+}
+
+// Commands
+
+func monitorTick(id int) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return monitorTickMsg{id: id}
+	})
+}
+
+func waitForTransition(mon *monitor) tea.Cmd {
+	return func() tea.Msg {
+		return monitorEventMsg{event: <-mon.transitions}
+	}
+}
+
+func loadMonitorConfig() tea.Cmd {
+	return func() tea.Msg {
+		dir, err := common.ConfigDir()
+		if err != nil {
+			return monitorLoadedMsg{err: err}
+		}
+
+		path := filepath.Join(dir, "monitors.json")
+		b, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return monitorLoadedMsg{path: path}
+		}
+		if err != nil {
+			return monitorLoadedMsg{path: path, err: err}
+		}
+
+		config := monitorConfig{}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&config); err != nil {
+			return monitorLoadedMsg{path: path, err: fmt.Errorf("%s: %w", path, err)}
+		}
+
+		for i, e := range config.Endpoints {
+			if strings.TrimSpace(e.Target) == "" {
+				return monitorLoadedMsg{path: path, err: fmt.Errorf("%s: endpoint %d has no target", path, i)}
+			}
+			if e.Name == "" {
+				config.Endpoints[i].Name = e.Target
+			}
+		}
+
+		return monitorLoadedMsg{path: path, config: config}
+	}
+}
@@ -0,0 +1,548 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-live/internal/common"
+)
+
+// socket is a listening TCP socket or a bound UDP one.
+type socket struct {
+	proto   string
+	ip      net.IP
+	port    int
+	inode   string
+	user    string
+	pid     int // 0 when the owner can't be seen
+	command string
+}
+
+func (s socket) addr() string {
+	return net.JoinHostPort(s.ip.String(), strconv.Itoa(s.port))
+}
+
+func (s socket) row() table.Row {
+	pid := "-"
+	if s.pid > 0 {
+		pid = strconv.Itoa(s.pid)
+	}
+
+	return table.Row{s.proto, s.ip.String(), strconv.Itoa(s.port), pid, s.command, s.user}
+}
+
+var portColumns = []table.Column{
+	{Title: "Proto", Width: 7},
+	{Title: "Address", Width: 24},
+	{Title: "Port", Width: 7},
+	{Title: "PID", Width: 8},
+	{Title: "Command", Width: 40},
+	{Title: "User", Width: 12},
+}
+
+type portsMsg struct {
+	sockets []socket
+	err     error
+}
+
+type signalledMsg struct {
+	pid    int
+	signal string
+	err    error
+}
+
+type portsKeymap struct {
+	Filter  key.Binding
+	Left    key.Binding
+	Right   key.Binding
+	Sort    key.Binding
+	Copy    key.Binding
+	Signal  key.Binding
+	Refresh key.Binding
+}
+
+var portsKeys = portsKeymap{
+	Filter: dataKeys.Filter,
+	Left:   dataKeys.Left,
+	Right:  dataKeys.Right,
+	Sort:   dataKeys.Sort,
+	Copy: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "copy address"),
+	),
+	Signal: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "signal process"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh"),
+	),
+}
+
+// portsModel lists the sockets listening on this machine and the processes
+// they belong to.
+type portsModel struct {
+	keys    portsKeymap
+	table   table.Model
+	input   textinput.Model
+	mode    inputMode
+	sockets []socket
+	view    []int // indexes into sockets, filtered and sorted
+	column  int
+	sortCol int
+	desc    bool
+	filter  string
+	target  socket // whose process the signal prompt is for
+	focused bool
+	status  string
+	err     error
+}
+
+func newPorts() portsModel {
+	return portsModel{
+		keys:    portsKeys,
+		table:   newTable(),
+		input:   textinput.New(),
+		sortCol: 2,
+	}
+}
+
+func (m *portsModel) Focus() tea.Cmd {
+	m.focused = true
+	m.table.Focus()
+
+	return scanPorts()
+}
+
+func (m *portsModel) Blur() {
+	m.focused = false
+	m.mode = inputNone
+	m.input.Blur()
+	m.table.Blur()
+}
+
+func (m portsModel) Focused() bool {
+	return m.focused
+}
+
+func (m portsModel) Typing() bool {
+	return m.mode != inputNone
+}
+
+func (m *portsModel) SetSize(width, height int) {
+	m.table.SetHeight(max(height-6, 3))
+	m.input.Width = max(width-30, 10)
+}
+
+func (m portsModel) Update(msg tea.Msg) (portsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case portsMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.sockets = msg.sockets
+			m.refresh()
+		}
+		return m, nil
+
+	case signalledMsg:
+		m.err = msg.err
+		if msg.err != nil {
+			return m, nil
+		}
+
+		m.status = fmt.Sprintf("Sent %s to %d", msg.signal, msg.pid)
+		// Give the process a moment to go away before looking again
+		return m, tea.Tick(500*time.Millisecond, func(time.Time) tea.Msg {
+			return scanPorts()()
+		})
+
+	case tea.KeyMsg:
+		if m.mode != inputNone {
+			return m.updateInput(msg)
+		}
+
+		selected, ok := m.selected()
+
+		switch {
+		case key.Matches(msg, m.keys.Refresh):
+			return m, scanPorts()
+		case key.Matches(msg, m.keys.Filter):
+			m.mode = inputFilter
+			m.input.Prompt = "Filter: "
+			m.input.SetValue(m.filter)
+			m.input.CursorEnd()
+			return m, m.input.Focus()
+		case key.Matches(msg, m.keys.Left):
+			if m.column > 0 {
+				m.column--
+				m.setColumns()
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Right):
+			if m.column < len(portColumns)-1 {
+				m.column++
+				m.setColumns()
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Sort):
+			m.desc = m.sortCol == m.column && !m.desc
+			m.sortCol = m.column
+			m.refresh()
+			return m, nil
+		case key.Matches(msg, m.keys.Copy):
+			if !ok {
+				return m, nil
+			}
+			m.status = "Copied " + selected.addr()
+			return m, common.Copy(selected.addr())
+		case key.Matches(msg, m.keys.Signal):
+			if !ok || selected.pid == 0 {
+				m.status = "The owning process isn't visible, try running as root"
+				return m, nil
+			}
+
+			m.mode = inputSignal
+			m.target = selected
+			m.input.Prompt = fmt.Sprintf("Signal for %d (%s): ", selected.pid, truncate(selected.command, 20))
+			m.input.SetValue("TERM")
+			m.input.CursorEnd()
+			return m, m.input.Focus()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.table, cmd = m.table.Update(msg)
+
+	return m, cmd
+}
+
+func (m portsModel) updateInput(msg tea.KeyMsg) (portsModel, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = inputNone
+		m.input.Blur()
+		return m, nil
+
+	case tea.KeyEnter:
+		value := strings.TrimSpace(m.input.Value())
+		mode := m.mode
+		m.mode = inputNone
+		m.input.Blur()
+
+		switch mode {
+		case inputFilter:
+			m.filter = value
+			m.refresh()
+		case inputSignal:
+			if value != "" {
+				return m, signalProcess(m.target.pid, value)
+			}
+		}
+
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+
+	return m, cmd
+}
+
+func (m portsModel) selected() (socket, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.view) {
+		return socket{}, false
+	}
+
+	return m.sockets[m.view[i]], true
+}
+
+// refresh applies the filter and sort to the sockets and hands them to the
+// table.
+func (m *portsModel) refresh() {
+	terms, err := parseFilter(columnTitles(portColumns), m.filter)
+	m.err = err
+
+	rows := make([]table.Row, len(m.sockets))
+	m.view = m.view[:0]
+	for i, s := range m.sockets {
+		rows[i] = s.row()
+		if err != nil || matchAll(terms, rows[i]) {
+			m.view = append(m.view, i)
+		}
+	}
+
+	slices.SortStableFunc(m.view, func(a, b int) int {
+		if m.desc {
+			return compareCells(rows[b][m.sortCol], rows[a][m.sortCol])
+		}
+		return compareCells(rows[a][m.sortCol], rows[b][m.sortCol])
+	})
+
+	shown := make([]table.Row, len(m.view))
+	for i, r := range m.view {
+		shown[i] = rows[r]
+	}
+
+	m.setColumns()
+	m.table.SetRows(shown)
+	m.table.SetCursor(min(m.table.Cursor(), max(len(shown)-1, 0)))
+}
+
+// setColumns marks the selected and sorted columns in the headers.
+func (m *portsModel) setColumns() {
+	cols := slices.Clone(portColumns)
+	for i := range cols {
+		if i == m.sortCol && m.desc {
+			cols[i].Title += " ▼"
+		} else if i == m.sortCol {
+			cols[i].Title += " ▲"
+		}
+		if i == m.column {
+			cols[i].Title = "[" + cols[i].Title + "]"
+		}
+	}
+
+	m.table.SetColumns(cols)
+}
+
+func columnTitles(cols []table.Column) []string {
+	titles := make([]string, len(cols))
+	for i, c := range cols {
+		titles[i] = c.Title
+	}
+
+	return titles
+}
+
+func (m portsModel) View() string {
+	s := []string{tableStyle.Render(m.table.View())}
+	info := fmt.Sprintf("%d/%d sockets", len(m.view), len(m.sockets))
+	if m.filter != "" {
+		info += " · filter: " + m.filter
+	}
+	s = append(s, mutedStyle.Render(info))
+
+	if m.mode != inputNone {
+		s = append(s, m.input.View())
+	} else {
+		s = append(s, mutedStyle.Render("/ filter (text, port=8080, proto~udp) · ←/→ column · s sort · c copy · K signal · r refresh · esc back"))
+	}
+
+	if m.err != nil {
+		s = append(s, errStyle.Render(m.err.Error()))
+	} else if m.status != "" {
+		s = append(s, m.status)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, s...)
+}
+
+// Commands
+
+func scanPorts() tea.Cmd {
+	return func() tea.Msg {
+		sockets, err := listSockets()
+		return portsMsg{sockets: sockets, err: err}
+	}
+}
+
+func signalProcess(pid int, signal string) tea.Cmd {
+	return func() tea.Msg {
+		return signalledMsg{pid: pid, signal: signal, err: sendSignal(pid, signal)}
+	}
+}
+
+// Reading /proc
+
+const (
+	tcpListen = "0A"
+	udpUnconn = "07"
+)
+
+// listSockets reads the listening sockets from /proc/net and finds their
+// processes through the socket links in /proc/*/fd. Only processes this user
+// can see get one, the rest are left without.
+func listSockets() ([]socket, error) {
+	sockets := []socket{}
+	found := false
+
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		state := tcpListen
+		if strings.HasPrefix(proto, "udp") {
+			state = udpUnconn
+		}
+
+		s, err := readProcNet(filepath.Join("/proc/net", proto), proto, state)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		found = true
+		sockets = append(sockets, s...)
+	}
+
+	if !found {
+		return nil, errors.New("listening sockets are read from /proc/net, which this system doesn't have")
+	}
+
+	owners := socketOwners()
+	users := map[string]string{}
+	for i, s := range sockets {
+		if pid, ok := owners[s.inode]; ok {
+			sockets[i].pid = pid
+			sockets[i].command = processCommand(pid)
+		}
+
+		name, ok := users[s.user]
+		if !ok {
+			name = s.user
+			if u, err := user.LookupId(s.user); err == nil {
+				name = u.Username
+			}
+			users[s.user] = name
+		}
+		sockets[i].user = name
+	}
+
+	return sockets, nil
+}
+
+// readProcNet reads the sockets in state from a /proc/net table:
+//
+//	sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+//	 0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 41525
+func readProcNet(path, proto, state string) ([]socket, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sockets := []socket{}
+	sc := bufio.NewScanner(f)
+	sc.Scan() // header
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 10 || fields[3] != state {
+			continue
+		}
+
+		ip, port, err := parseHexAddr(fields[1])
+		if err != nil {
+			continue
+		}
+
+		sockets = append(sockets, socket{
+			proto: proto,
+			ip:    ip,
+			port:  port,
+			user:  fields[7],
+			inode: fields[9],
+		})
+	}
+
+	return sockets, sc.Err()
+}
+
+// parseHexAddr reads an address like 0100007F:1F90. The IP is stored as
+// 32-bit words in host byte order, little endian on the machines this runs
+// on.
+func parseHexAddr(s string) (net.IP, int, error) {
+	host, port, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, 0, fmt.Errorf("bad address %q", s)
+	}
+
+	b, err := hex.DecodeString(host)
+	if err != nil || (len(b) != 4 && len(b) != 16) {
+		return nil, 0, fmt.Errorf("bad address %q", s)
+	}
+
+	for i := 0; i < len(b); i += 4 {
+		b[i], b[i+1], b[i+2], b[i+3] = b[i+3], b[i+2], b[i+1], b[i]
+	}
+
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("bad port %q", s)
+	}
+
+	return net.IP(b), int(p), nil
+}
+
+// socketOwners maps socket inodes to the lowest PID holding them.
+func socketOwners() map[string]int {
+	owners := map[string]int{}
+
+	procs, _ := os.ReadDir("/proc")
+	for _, p := range procs {
+		pid, err := strconv.Atoi(p.Name())
+		if err != nil {
+			continue
+		}
+
+		dir := filepath.Join("/proc", p.Name(), "fd")
+		fds, err := os.ReadDir(dir)
+		if err != nil {
+			// Someone else's process
+			continue
+		}
+
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(dir, fd.Name()))
+			if err != nil {
+				continue
+			}
+
+			inode, ok := strings.CutPrefix(link, "socket:[")
+			if !ok {
+				continue
+			}
+
+			// Forked workers share the listener, the lowest PID is usually
+			// the parent that opened it. /proc lists PIDs as text, so
+			// 1000 comes before 999.
+			inode = strings.TrimSuffix(inode, "]")
+			if owner, ok := owners[inode]; !ok || pid < owner {
+				owners[inode] = pid
+			}
+		}
+	}
+
+	return owners
+}
+
+// processCommand returns the command line of pid, or its name if that's
+// empty as it is for kernel threads.
+func processCommand(pid int) string {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+
+	if b, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil && len(b) > 0 {
+		return strings.TrimSpace(strings.ReplaceAll(string(b), "\x00", " "))
+	}
+
+	b, _ := os.ReadFile(filepath.Join(dir, "comm"))
+
+	return strings.TrimSpace(string(b))
+}
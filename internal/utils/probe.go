@@ -0,0 +1,552 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-live/internal/common"
+)
+
+const (
+	probeURL = iota
+	probeConcurrency
+	probeRequests
+)
+
+var (
+	labelStyle = lipgloss.NewStyle().Width(13).Foreground(lipgloss.Color("241"))
+	barStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#6A6094"))
+	mutedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+type probeResult struct {
+	latency time.Duration
+	status  int
+	err     error
+}
+
+// probeRun is the summary of a finished probe, saved so runs can be compared.
+type probeRun struct {
+	Time        time.Time     `json:"time"`
+	URL         string        `json:"url"`
+	Concurrency int           `json:"concurrency"`
+	Requests    int           `json:"requests"`
+	Duration    time.Duration `json:"duration"`
+	P50         time.Duration `json:"p50"`
+	P95         time.Duration `json:"p95"`
+	P99         time.Duration `json:"p99"`
+	Errors      int           `json:"errors"`
+	Statuses    map[int]int   `json:"statuses"`
+}
+
+type probeResultsMsg struct {
+	ch      <-chan probeResult
+	results []probeResult
+	done    bool
+}
+
+type probeHistoryMsg struct {
+	runs []probeRun
+	err  error
+}
+
+// probeModel fires a number of GET requests at a URL with a fixed
+// concurrency and shows the latency distribution as results come in.
+type probeModel struct {
+	keys      common.Keymap
+	inputs    []textinput.Model
+	field     int
+	focused   bool
+	running   bool
+	cancelled bool
+	cancel    context.CancelFunc
+	results   <-chan probeResult
+	run       probeRun
+	latencies []time.Duration // sorted
+	done      int
+	started   time.Time
+	bar       progress.Model
+	history   []probeRun
+	err       error
+}
+
+func newProbe() probeModel {
+	inputs := make([]textinput.Model, 3)
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = ""
+	}
+
+	inputs[probeURL].Placeholder = "https://example.com/healthz"
+	inputs[probeURL].Width = 50
+	inputs[probeConcurrency].SetValue("10")
+	inputs[probeConcurrency].Width = 6
+	inputs[probeRequests].SetValue("100")
+	inputs[probeRequests].Width = 6
+
+	return probeModel{
+		keys:   common.Keys,
+		inputs: inputs,
+		bar:    progress.New(progress.WithScaledGradient("#6A6094", "#FF6E81")),
+	}
+}
+
+func (m *probeModel) Focus() tea.Cmd {
+	m.focused = true
+
+	return tea.Batch(m.inputs[m.field].Focus(), loadProbeHistory())
+}
+
+func (m *probeModel) Blur() {
+	m.focused = false
+	for i := range m.inputs {
+		m.inputs[i].Blur()
+	}
+}
+
+func (m probeModel) Focused() bool {
+	return m.focused
+}
+
+func (m probeModel) Typing() bool {
+	return m.focused && !m.running
+}
+
+func (m probeModel) Update(msg tea.Msg) (probeModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case probeHistoryMsg:
+		m.err = msg.err
+		if msg.runs != nil {
+			m.history = msg.runs
+		}
+		return m, nil
+
+	case probeResultsMsg:
+		if msg.ch != m.results {
+			return m, nil
+		}
+
+		m.record(msg.results)
+		if !msg.done {
+			return m, waitForProbe(m.results)
+		}
+
+		m.running = false
+		m.cancel()
+		m.run.Duration = time.Since(m.started)
+
+		// A partial run would skew the comparison with finished ones
+		if m.cancelled {
+			return m, nil
+		}
+		m.history = append([]probeRun{m.run}, m.history...)
+
+		return m, saveProbeRun(m.run)
+
+	case tea.KeyMsg:
+		if m.running {
+			if key.Matches(msg, m.keys.Back) {
+				m.cancelled = true
+				m.cancel()
+			}
+
+			return m, nil
+		}
+
+		switch msg.Type {
+		case tea.KeyTab, tea.KeyDown:
+			return m, m.focusField(m.field + 1)
+		case tea.KeyShiftTab, tea.KeyUp:
+			return m, m.focusField(m.field - 1)
+		case tea.KeyEnter:
+			return m.start()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.field], cmd = m.inputs[m.field].Update(msg)
+
+	return m, cmd
+}
+
+func (m *probeModel) focusField(i int) tea.Cmd {
+	m.inputs[m.field].Blur()
+	m.field = (i + len(m.inputs)) % len(m.inputs)
+
+	return m.inputs[m.field].Focus()
+}
+
+func (m probeModel) start() (probeModel, tea.Cmd) {
+	url := strings.TrimSpace(m.inputs[probeURL].Value())
+	if !strings.Contains(url, "://") {
+		url = "https://" + url
+	}
+
+	concurrency, err1 := strconv.Atoi(m.inputs[probeConcurrency].Value())
+	requests, err2 := strconv.Atoi(m.inputs[probeRequests].Value())
+
+	switch {
+	case url == "https://":
+		m.err = errors.New("enter a URL to probe")
+	case err1 != nil || concurrency < 1:
+		m.err = errors.New("concurrency must be a positive number")
+	case err2 != nil || requests < 1:
+		m.err = errors.New("requests must be a positive number")
+	default:
+		m.err = nil
+	}
+
+	if m.err != nil {
+		return m, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.results = probe(ctx, url, min(concurrency, requests), requests)
+	m.running, m.cancelled = true, false
+	m.started = time.Now()
+	m.latencies = nil
+	m.done = 0
+	m.run = probeRun{
+		Time:        m.started,
+		URL:         url,
+		Concurrency: concurrency,
+		Requests:    requests,
+		Statuses:    map[int]int{},
+	}
+
+	return m, waitForProbe(m.results)
+}
+
+// record adds results to the current run and refreshes its percentiles.
+func (m *probeModel) record(results []probeResult) {
+	for _, r := range results {
+		// Requests cut short by esc didn't fail
+		if m.cancelled && errors.Is(r.err, context.Canceled) {
+			continue
+		}
+
+		m.done++
+		if r.err != nil {
+			m.run.Errors++
+			continue
+		}
+
+		m.run.Statuses[r.status]++
+		m.latencies = append(m.latencies, r.latency)
+	}
+
+	slices.Sort(m.latencies)
+
+	m.run.P50 = percentile(m.latencies, 0.50)
+	m.run.P95 = percentile(m.latencies, 0.95)
+	m.run.P99 = percentile(m.latencies, 0.99)
+}
+
+func (m probeModel) View() string {
+	s := []string{}
+	labels := []string{"URL", "Concurrency", "Requests"}
+	for i, in := range m.inputs {
+		s = append(s, labelStyle.Render(labels[i])+in.View())
+	}
+
+	if m.running {
+		s = append(s, mutedStyle.Render("esc to cancel"))
+	} else {
+		s = append(s, mutedStyle.Render("tab to move · enter to run · esc to go back"))
+	}
+
+	if m.err != nil {
+		s = append(s, errStyle.Render(m.err.Error()))
+	}
+
+	if m.run.Requests > 0 {
+		elapsed := m.run.Duration
+		if m.running {
+			elapsed = time.Since(m.started)
+		}
+
+		s = append(s,
+			"",
+			m.bar.ViewAs(float64(m.done)/float64(m.run.Requests)),
+			fmt.Sprintf("%d/%d requests · %s · %d errors%s", m.done, m.run.Requests, elapsed.Round(time.Millisecond), m.run.Errors, m.cancelledView()),
+			fmt.Sprintf("p50 %s · p95 %s · p99 %s", ms(m.run.P50), ms(m.run.P95), ms(m.run.P99)),
+			"Status "+statusesView(m.run.Statuses),
+			"",
+			histogramView(m.latencies, 8, 40),
+		)
+	}
+
+	if len(m.history) > 0 {
+		s = append(s, "", titleStyle.MarginBottom(0).Render("Previous runs"), m.historyView(5))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, s...)
+}
+
+func (m probeModel) cancelledView() string {
+	if !m.cancelled {
+		return ""
+	}
+
+	return mutedStyle.Render(" · cancelled, not saved")
+}
+
+// historyView lists the latest saved runs against the same URL.
+func (m probeModel) historyView(n int) string {
+	url := m.run.URL
+	if url == "" {
+		url = m.history[0].URL
+	}
+
+	rows := []string{mutedStyle.Render(fmt.Sprintf("%-19s %5s %6s %9s %9s %9s %6s", "Time", "Conc", "Reqs", "p50", "p95", "p99", "Errors"))}
+	for _, r := range m.history {
+		if r.URL != url || len(rows) > n {
+			continue
+		}
+
+		rows = append(rows, fmt.Sprintf("%-19s %5d %6d %9s %9s %9s %6d",
+			r.Time.Format("2006-01-02 15:04:05"), r.Concurrency, r.Requests, ms(r.P50), ms(r.P95), ms(r.P99), r.Errors))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+func statusesView(statuses map[int]int) string {
+	codes := make([]int, 0, len(statuses))
+	for c := range statuses {
+		codes = append(codes, c)
+	}
+	sort.Ints(codes)
+
+	s := make([]string, 0, len(codes))
+	for _, c := range codes {
+		s = append(s, fmt.Sprintf("%d ×%d", c, statuses[c]))
+	}
+
+	if len(s) == 0 {
+		return "-"
+	}
+
+	return strings.Join(s, " · ")
+}
+
+// histogramView renders sorted latencies as buckets of equal width between
+// the fastest and slowest request.
+func histogramView(sorted []time.Duration, buckets, width int) string {
+	if len(sorted) == 0 {
+		return ""
+	}
+
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	step := (hi - lo) / time.Duration(buckets)
+	if step <= 0 {
+		step, buckets = 1, 1
+	}
+
+	counts := make([]int, buckets)
+	peak := 0
+	for _, d := range sorted {
+		b := min(int((d-lo)/step), buckets-1)
+		counts[b]++
+		peak = max(peak, counts[b])
+	}
+
+	rows := []string{}
+	for i, c := range counts {
+		from := lo + time.Duration(i)*step
+		bar := strings.Repeat("█", c*width/peak)
+		rows = append(rows, fmt.Sprintf("%9s %s %d", ms(from), barStyle.Render(bar), c))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	return sorted[min(int(float64(len(sorted))*p), len(sorted)-1)]
+}
+
+func ms(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
+
+// Commands
+
+// probe sends requests GETs to url from concurrency workers. Results are
+// delivered on the returned channel, which is closed once every request is
+// done or ctx is cancelled.
+func probe(ctx context.Context, url string, concurrency, requests int) <-chan probeResult {
+	results := make(chan probeResult, concurrency)
+	jobs := make(chan struct{}, requests)
+	for i := 0; i < requests; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+
+	// Without enough idle connections per host every worker past the second
+	// would dial a new one for each request, measuring handshakes instead
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConnsPerHost = concurrency
+	c := &http.Client{
+		Transport: t,
+		Timeout:   10 * time.Second,
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+
+				results <- get(ctx, c, url)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		t.CloseIdleConnections()
+		close(results)
+	}()
+
+	return results
+}
+
+func get(ctx context.Context, c *http.Client, url string) probeResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return probeResult{err: err}
+	}
+
+	start := time.Now()
+	res, err := c.Do(req)
+	if err != nil {
+		return probeResult{err: err}
+	}
+	defer res.Body.Close()
+
+	// The body has to be read to the end for the connection to be reused,
+	// and the latency covers it like a real client's would
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		return probeResult{err: err}
+	}
+
+	return probeResult{latency: time.Since(start), status: res.StatusCode}
+}
+
+// waitForProbe waits for the next results, batching whatever else is already
+// queued so a fast endpoint doesn't flood the update loop.
+func waitForProbe(ch <-chan probeResult) tea.Cmd {
+	return func() tea.Msg {
+		r, ok := <-ch
+		if !ok {
+			return probeResultsMsg{ch: ch, done: true}
+		}
+
+		batch := []probeResult{r}
+		for len(batch) < 512 {
+			select {
+			case r, ok := <-ch:
+				if !ok {
+					return probeResultsMsg{ch: ch, results: batch, done: true}
+				}
+				batch = append(batch, r)
+			default:
+				return probeResultsMsg{ch: ch, results: batch}
+			}
+		}
+
+		return probeResultsMsg{ch: ch, results: batch}
+	}
+}
+
+func probeHistoryPath() (string, error) {
+	dir, err := common.StateDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "probes.jsonl"), nil
+}
+
+// loadProbeHistory reads the saved runs, newest first.
+func loadProbeHistory() tea.Cmd {
+	return func() tea.Msg {
+		path, err := probeHistoryPath()
+		if err != nil {
+			return probeHistoryMsg{err: err}
+		}
+
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return probeHistoryMsg{}
+		}
+		if err != nil {
+			return probeHistoryMsg{err: err}
+		}
+		defer f.Close()
+
+		runs := []probeRun{}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			r := probeRun{}
+			if json.Unmarshal(sc.Bytes(), &r) == nil {
+				runs = append([]probeRun{r}, runs...)
+			}
+		}
+
+		return probeHistoryMsg{runs: runs, err: sc.Err()}
+	}
+}
+
+func saveProbeRun(r probeRun) tea.Cmd {
+	return func() tea.Msg {
+		path, err := probeHistoryPath()
+		if err != nil {
+			return probeHistoryMsg{err: err}
+		}
+
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return probeHistoryMsg{err: err}
+		}
+		defer f.Close()
+
+		b, err := json.Marshal(r)
+		if err == nil {
+			_, err = f.Write(append(b, '\n'))
+		}
+		if err != nil {
+			return probeHistoryMsg{err: err}
+		}
+
+		return nil
+	}
+}
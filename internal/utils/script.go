@@ -0,0 +1,370 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-live/internal/common"
+	"go-live/internal/directive"
+	"go-live/internal/logview"
+)
+
+// scriptRun is a running command. Its output is read from stdout and stderr
+// in the background and handed to the model in batches through lines.
+type scriptRun struct {
+	cmd   *exec.Cmd
+	lines chan logview.Line
+	done  chan struct{}
+	once  sync.Once
+	err   error // set before lines is closed
+}
+
+type scriptStartedMsg struct {
+	run *scriptRun
+	err error
+}
+
+type scriptOutputMsg struct {
+	run   *scriptRun
+	lines []logview.Line
+	done  bool
+	err   error
+}
+
+// scriptModel runs a shell command, showing its output in a log pane and
+// driving a progress bar from the directive lines it prints.
+type scriptModel struct {
+	input   textinput.Model
+	bar     progress.Model
+	log     logview.Model
+	run     *scriptRun
+	command string
+	running bool
+	percent float64
+	status  string
+	started time.Time
+	ended   time.Time
+	exit    error
+	focused bool
+	err     error
+}
+
+func newScript() scriptModel {
+	input := textinput.New()
+	input.Prompt = "Command: "
+	input.Placeholder = "./build.sh, printing lines like ::progress 40 Building assets"
+	input.Width = 60
+
+	return scriptModel{
+		input: input,
+		bar:   progress.New(progress.WithScaledGradient("#6A6094", "#FF6E81")),
+		log:   logview.New(80, 20),
+	}
+}
+
+func (m *scriptModel) Focus() tea.Cmd {
+	m.focused = true
+	if m.running {
+		return nil
+	}
+
+	return m.input.Focus()
+}
+
+func (m *scriptModel) Blur() {
+	m.focused = false
+	m.input.Blur()
+}
+
+func (m scriptModel) Focused() bool {
+	return m.focused
+}
+
+// Typing reports whether keys are going to the command prompt or the log
+// search.
+func (m scriptModel) Typing() bool {
+	return (m.focused && !m.running) || m.log.Typing()
+}
+
+func (m *scriptModel) SetSize(width, height int) {
+	m.bar.Width = max(min(width-4, 80), 10)
+	m.log.SetSize(width, max(height-6, 4))
+}
+
+func (m scriptModel) Update(msg tea.Msg) (scriptModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case scriptStartedMsg:
+		m.err = msg.err
+		if msg.err != nil {
+			m.input.Focus()
+			return m, nil
+		}
+
+		m.log.Close()
+		m.log.Reset()
+		dir, err := common.StateDir("logs")
+		if err == nil {
+			name := fmt.Sprintf("script-%s.log", time.Now().Format("20060102-150405"))
+			err = m.log.SaveTo(filepath.Join(dir, name))
+		}
+		if err != nil {
+			m.log.ShowError(fmt.Errorf("log not saved: %w", err))
+		}
+
+		m.run, m.running = msg.run, true
+		m.percent, m.status, m.exit = 0, "", nil
+		m.started = time.Now()
+		return m, m.run.next()
+
+	case scriptOutputMsg:
+		if msg.run != m.run || !m.running {
+			return m, nil
+		}
+
+		for _, l := range msg.lines {
+			if p, ok := directive.Parse(l.Text); ok {
+				if p.Percent >= 0 {
+					m.percent = p.Percent
+				}
+				if p.Status != "" {
+					m.status = p.Status
+				}
+				continue
+			}
+			m.log.Append(l)
+		}
+
+		if !msg.done {
+			return m, m.run.next()
+		}
+
+		m.finish(msg.err)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.running {
+			if msg.Type == tea.KeyEsc && !m.log.Typing() {
+				m.stop()
+				return m, nil
+			}
+			break
+		}
+
+		switch msg.Type {
+		case tea.KeyEnter:
+			command := strings.TrimSpace(m.input.Value())
+			if command == "" {
+				return m, nil
+			}
+
+			m.command = command
+			m.input.Blur()
+			return m, startScript(command)
+		case tea.KeyUp, tea.KeyDown, tea.KeyPgUp, tea.KeyPgDown:
+			// Scroll the last run's output
+		default:
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	m.log, cmd = m.log.Update(msg)
+
+	return m, cmd
+}
+
+func (m *scriptModel) finish(err error) {
+	m.running = false
+	m.ended = time.Now()
+	m.exit = err
+	if err == nil {
+		m.percent = 1
+	}
+	m.log.Close()
+
+	if m.focused {
+		m.input.Focus()
+	}
+}
+
+func (m *scriptModel) stop() {
+	if m.run != nil && m.running {
+		m.run.stop()
+	}
+
+	m.finish(errors.New("stopped"))
+}
+
+// Stop kills the script's whole process group, anything it started in the
+// background included.
+func (m scriptModel) Stop() {
+	if m.run != nil && m.running {
+		m.run.stop()
+	}
+	m.log.Close()
+}
+
+func (m scriptModel) View() string {
+	s := []string{}
+
+	if m.running {
+		s = append(s, fmt.Sprintf("%s %s", m.command, mutedStyle.Render("· started "+m.started.Format("15:04:05"))))
+	} else {
+		s = append(s, m.input.View())
+	}
+
+	if m.run != nil {
+		s = append(s, "", m.bar.ViewAs(m.percent), m.statusView(), "", m.log.View())
+	}
+
+	if m.err != nil {
+		s = append(s, errStyle.Render(m.err.Error()))
+	}
+
+	help := "enter to run · esc to go back"
+	if m.running {
+		help = "esc to stop"
+	}
+	s = append(s, mutedStyle.Render(help))
+
+	return lipgloss.JoinVertical(lipgloss.Left, s...)
+}
+
+func (m scriptModel) statusView() string {
+	switch {
+	case m.running:
+		return m.status
+	case m.exit != nil:
+		return errStyle.Render(m.exit.Error()) + " " + mutedStyle.Render(m.status)
+	default:
+		return upStyle.Render(fmt.Sprintf("finished in %s", m.ended.Sub(m.started).Round(time.Millisecond)))
+	}
+}
+
+// Summary describes the current or last run for the Utils menu.
+func (m scriptModel) Summary() (string, bool) {
+	if m.run == nil {
+		return "", false
+	}
+
+	s := fmt.Sprintf("%s %s %s", truncate(m.command, 30), m.bar.ViewAs(m.percent), m.status)
+	if !m.running {
+		s = fmt.Sprintf("%s %s", truncate(m.command, 30), m.statusView())
+	}
+
+	return s, true
+}
+
+// Commands
+
+func startScript(command string) tea.Cmd {
+	return func() tea.Msg {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Env = append(os.Environ(), "GO_LIVE_PROGRESS=1")
+		setProcessGroup(cmd)
+
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return scriptStartedMsg{err: err}
+		}
+		stderr, err := cmd.StderrPipe()
+		if err != nil {
+			return scriptStartedMsg{err: err}
+		}
+
+		if err := cmd.Start(); err != nil {
+			return scriptStartedMsg{err: err}
+		}
+
+		run := &scriptRun{
+			cmd:   cmd,
+			lines: make(chan logview.Line, 1024),
+			done:  make(chan struct{}),
+		}
+
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+		go run.read(stdout, logview.Stdout, &wg)
+		go run.read(stderr, logview.Stderr, &wg)
+		go func() {
+			wg.Wait()
+			run.err = cmd.Wait()
+			close(run.lines)
+		}()
+
+		return scriptStartedMsg{run: run}
+	}
+}
+
+func (r *scriptRun) read(rd io.Reader, stream logview.Stream, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	err := logview.ReadLines(rd, func(line []byte, cut bool) bool {
+		if !r.send(logview.Line{Time: time.Now(), Stream: stream, Text: string(line)}) {
+			return false
+		}
+		if cut {
+			return r.send(logview.Line{Time: time.Now(), Stream: logview.Stderr, Text: "go-live: line over 1 MiB cut short"})
+		}
+		return true
+	})
+	if err != nil {
+		r.send(logview.Line{Time: time.Now(), Stream: logview.Stderr, Text: "go-live: " + err.Error()})
+	}
+}
+
+// send queues l for the model, giving up once the run has been stopped.
+func (r *scriptRun) send(l logview.Line) bool {
+	select {
+	case r.lines <- l:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+// next waits for more output, batching whatever is already waiting.
+func (r *scriptRun) next() tea.Cmd {
+	return func() tea.Msg {
+		l, ok := <-r.lines
+		if !ok {
+			return scriptOutputMsg{run: r, done: true, err: r.err}
+		}
+
+		batch := []logview.Line{l}
+		for len(batch) < 512 {
+			select {
+			case l, ok := <-r.lines:
+				if !ok {
+					return scriptOutputMsg{run: r, lines: batch, done: true, err: r.err}
+				}
+				batch = append(batch, l)
+			default:
+				return scriptOutputMsg{run: r, lines: batch}
+			}
+		}
+
+		return scriptOutputMsg{run: r, lines: batch}
+	}
+}
+
+func (r *scriptRun) stop() {
+	r.once.Do(func() {
+		close(r.done)
+		killProcessGroup(r.cmd)
+	})
+}
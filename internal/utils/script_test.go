@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go-live/internal/logview"
+)
+
+// runScript runs command through the model until it finishes.
+func runScript(t *testing.T, command string) scriptModel {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("scripts run with sh")
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	m := newScript()
+	msgs := make(chan tea.Msg, 1)
+	cmd := startScript(command)
+
+	for {
+		if cmd == nil {
+			t.Fatal("the run stopped asking for output before it finished")
+		}
+		go func(cmd tea.Cmd) { msgs <- cmd() }(cmd)
+
+		select {
+		case msg := <-msgs:
+			m, cmd = m.Update(msg)
+		case <-time.After(10 * time.Second):
+			m.stop()
+			t.Fatal("timed out waiting for the script")
+		}
+
+		if m.run != nil && !m.running {
+			return m
+		}
+	}
+}
+
+func TestScriptLongLine(t *testing.T) {
+	m := runScript(t, "head -c 2000000 /dev/zero | tr '\\0' a; echo; echo ::progress 50 Halfway; echo after")
+
+	if m.exit != nil {
+		t.Fatalf("exit = %v", m.exit)
+	}
+	if m.status != "Halfway" {
+		t.Errorf("status = %q, want the one after the long line", m.status)
+	}
+
+	lines := m.log.Lines()
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.Text
+	}
+	if len(lines) != 3 || len(texts[0]) != logview.MaxLineBytes || !strings.Contains(texts[1], "cut short") || texts[2] != "after" {
+		t.Errorf("got %d lines, want the cut line, a note and \"after\"", len(lines))
+	}
+}
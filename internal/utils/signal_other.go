@@ -0,0 +1,25 @@
+//go:build !unix
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// sendSignal can only kill the process, other signals need a unix system.
+func sendSignal(pid int, name string) error {
+	switch strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "SIG") {
+	case "KILL", "9":
+	default:
+		return errors.New("only KILL is supported on this system")
+	}
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	return p.Kill()
+}
@@ -0,0 +1,39 @@
+//go:build unix
+
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var signals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"TERM": syscall.SIGTERM,
+	"CONT": syscall.SIGCONT,
+	"STOP": syscall.SIGSTOP,
+}
+
+// sendSignal sends the signal called name, like TERM or SIGTERM, or numbered
+// name to pid.
+func sendSignal(pid int, name string) error {
+	name = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "SIG")
+
+	sig, ok := signals[name]
+	if !ok {
+		n, err := strconv.Atoi(name)
+		if err != nil {
+			return fmt.Errorf("unknown signal %q", name)
+		}
+		sig = syscall.Signal(n)
+	}
+
+	return syscall.Kill(pid, sig)
+}
@@ -0,0 +1,396 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	pageRows   = 256  // rows read from disk at a time
+	cachePages = 64   // pages kept in memory, 16k rows
+	chunkRows  = 1e5  // rows indexed per command
+	headerScan = 1000 // JSON lines objects looked at for column names
+)
+
+// dataSource gives the table access to rows without holding all of them in
+// memory. Rows are addressed by their position in the file.
+type dataSource interface {
+	Header() []string
+	Len() int
+	Row(i int) ([]string, error)
+	// Scan calls fn with every row in order until it returns false. It reads
+	// through a handle of its own, so it's safe to run in a command.
+	Scan(fn func(i int, row []string) bool) error
+	Close() error
+}
+
+func (d *dataset) Header() []string {
+	return d.header
+}
+
+func (d *dataset) Len() int {
+	return len(d.rows)
+}
+
+func (d *dataset) Row(i int) ([]string, error) {
+	return d.rows[i], nil
+}
+
+func (d *dataset) Scan(fn func(i int, row []string) bool) error {
+	for i, row := range d.rows {
+		if !fn(i, row) {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (d *dataset) Close() error {
+	return nil
+}
+
+// fileSource reads CSV, TSV or JSON lines rows from disk on demand. It keeps
+// the byte offset of every row and a bounded cache of recently read pages.
+type fileSource struct {
+	path    string
+	format  dataFormat
+	f       *os.File
+	header  []string
+	offsets []int64 // where each indexed row starts
+	end     int64   // where the last indexed row ends
+	cache   map[int][][]string
+	order   []int // cached pages, oldest first
+}
+
+func (s *fileSource) Header() []string {
+	return s.header
+}
+
+func (s *fileSource) Len() int {
+	return len(s.offsets)
+}
+
+func (s *fileSource) Close() error {
+	return s.f.Close()
+}
+
+func (s *fileSource) Row(i int) ([]string, error) {
+	p := i / pageRows
+	page, ok := s.cache[p]
+	if !ok {
+		var err error
+		page, err = s.readPage(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(s.order) >= cachePages {
+			delete(s.cache, s.order[0])
+			s.order = s.order[1:]
+		}
+		s.cache[p] = page
+		s.order = append(s.order, p)
+	}
+
+	if i%pageRows >= len(page) {
+		return make([]string, len(s.header)), nil
+	}
+
+	return page[i%pageRows], nil
+}
+
+func (s *fileSource) readPage(p int) ([][]string, error) {
+	first := p * pageRows
+	last := min(first+pageRows, len(s.offsets))
+
+	end := s.end
+	if last < len(s.offsets) {
+		end = s.offsets[last]
+	}
+
+	r := io.NewSectionReader(s.f, s.offsets[first], end-s.offsets[first])
+	rows := make([][]string, 0, last-first)
+	err := s.read(r, func(row []string) bool {
+		rows = append(rows, row)
+		return len(rows) < last-first
+	})
+
+	return rows, err
+}
+
+// read parses rows from r, fitting each to the header.
+func (s *fileSource) read(r io.Reader, fn func(row []string) bool) error {
+	if s.format == formatJSONL {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for sc.Scan() {
+			line := bytes.TrimSpace(sc.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+
+			row := make([]string, len(s.header))
+			if obj, _, err := jsonObject(line); err == nil {
+				for i, h := range s.header {
+					row[i] = obj[h]
+				}
+			}
+
+			if !fn(row) {
+				return nil
+			}
+		}
+
+		return sc.Err()
+	}
+
+	cr := s.csvReader(r)
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !fn(fit(row, len(s.header))) {
+			return nil
+		}
+	}
+}
+
+func (s *fileSource) csvReader(r io.Reader) *csv.Reader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	if s.format == formatTSV {
+		cr.Comma = '\t'
+		cr.LazyQuotes = true
+	}
+
+	return cr
+}
+
+func (s *fileSource) Scan(fn func(i int, row []string) bool) error {
+	if len(s.offsets) == 0 {
+		return nil
+	}
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n := len(s.offsets)
+	r := io.NewSectionReader(f, s.offsets[0], s.end-s.offsets[0])
+	i := 0
+
+	return s.read(bufio.NewReaderSize(r, 1<<20), func(row []string) bool {
+		ok := fn(i, row)
+		i++
+		return ok && i < n
+	})
+}
+
+// indexer walks a file once, recording where each row starts. It works in
+// chunks so the table can show rows while the rest is still being indexed.
+type indexer struct {
+	src    *fileSource
+	f      *os.File
+	csv    *csv.Reader
+	lines  *bufio.Reader
+	offset int64
+	keys   map[string]bool
+	seen   int
+	chunk  int // rows per indexedMsg
+}
+
+type indexedMsg struct {
+	ix      *indexer
+	header  []string
+	offsets []int64
+	end     int64
+	done    bool
+	err     error
+}
+
+// openSource opens path as a lazily read source, except JSON arrays which
+// are loaded into memory. The returned indexer is nil when there's nothing
+// left to index.
+func openSource(path string) (dataSource, *indexer, error) {
+	format, ok := formatFor(path)
+	if !ok {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		first, _ := bufio.NewReader(f).Peek(4096)
+		f.Close()
+		if i := bytes.IndexByte(first, '\n'); i >= 0 {
+			first = first[:i]
+		}
+		format = sniffFormat(first)
+	}
+
+	if format == formatJSON {
+		d, err := loadDataset(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &d, nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idx, err := os.Open(path)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	src := &fileSource{path: path, format: format, f: f, cache: map[int][][]string{}}
+	ix := &indexer{src: src, f: idx, keys: map[string]bool{}, chunk: chunkRows}
+
+	if format == formatJSONL {
+		ix.lines = bufio.NewReaderSize(idx, 1<<20)
+	} else {
+		ix.csv = src.csvReader(idx)
+		ix.csv.ReuseRecord = true
+
+		header, err := ix.csv.Read()
+		if err == io.EOF {
+			err = errors.New("file is empty")
+		}
+		if err != nil {
+			f.Close()
+			idx.Close()
+			return nil, nil, err
+		}
+
+		src.header = append([]string(nil), header...)
+		ix.offset = ix.csv.InputOffset()
+	}
+
+	return src, ix, nil
+}
+
+// next indexes the next chunk of rows.
+func (ix *indexer) next() tea.Cmd {
+	return func() tea.Msg {
+		msg := indexedMsg{ix: ix, offsets: make([]int64, 0, ix.chunk)}
+
+		if ix.csv != nil {
+			for len(msg.offsets) < ix.chunk {
+				start := ix.offset
+				_, err := ix.csv.Read()
+				if err == io.EOF {
+					msg.done = true
+					break
+				}
+				if err != nil {
+					msg.err = err
+					break
+				}
+
+				ix.offset = ix.csv.InputOffset()
+				msg.offsets = append(msg.offsets, start)
+			}
+		} else {
+			for len(msg.offsets) < ix.chunk {
+				line, err := ix.lines.ReadBytes('\n')
+				start := ix.offset
+				ix.offset += int64(len(line))
+
+				if len(bytes.TrimSpace(line)) > 0 {
+					msg.offsets = append(msg.offsets, start)
+					if ix.seen < headerScan {
+						ix.seen++
+						_, keys, _ := jsonObject(line)
+						for _, k := range keys {
+							if !ix.keys[k] {
+								ix.keys[k] = true
+								msg.header = append(msg.header, k)
+							}
+						}
+					}
+				}
+
+				if err == io.EOF {
+					msg.done = true
+					break
+				}
+				if err != nil {
+					msg.err = err
+					break
+				}
+			}
+		}
+
+		msg.end = ix.offset
+		if msg.done || msg.err != nil {
+			ix.f.Close()
+		}
+
+		return msg
+	}
+}
+
+// add records an indexed chunk.
+func (s *fileSource) add(msg indexedMsg) {
+	// The last page may have been read before it was complete, and new
+	// columns leave every cached row short
+	if len(msg.header) > 0 {
+		clear(s.cache)
+		s.order = s.order[:0]
+	} else if n := len(s.offsets); n > 0 {
+		delete(s.cache, (n-1)/pageRows)
+	}
+
+	s.header = append(s.header, msg.header...)
+	s.offsets = append(s.offsets, msg.offsets...)
+	s.end = msg.end
+}
+
+// jsonObject decodes a JSON object into string cells, returning its keys in
+// order.
+func jsonObject(b []byte) (map[string]string, []string, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, errors.New("not a JSON object")
+	}
+
+	obj := map[string]string{}
+	keys := []string{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		k, _ := tok.(string)
+
+		raw := json.RawMessage{}
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+
+		if _, ok := obj[k]; !ok {
+			keys = append(keys, k)
+		}
+		obj[k] = jsonCell(raw)
+	}
+
+	return obj, keys, nil
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// indexFile opens content as a file called name and indexes all of it,
+// chunk rows at a time.
+func indexFile(t *testing.T, name, content string, chunk int) *fileSource {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, ix, err := openSource(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { src.Close() })
+
+	fs := src.(*fileSource)
+	ix.chunk = chunk
+	for {
+		msg := ix.next()().(indexedMsg)
+		if msg.err != nil {
+			t.Fatal(msg.err)
+		}
+		fs.add(msg)
+		if msg.done {
+			return fs
+		}
+	}
+}
+
+func checkRows(t *testing.T, src dataSource, want [][]string) {
+	t.Helper()
+
+	if src.Len() != len(want) {
+		t.Fatalf("got %d rows, want %d", src.Len(), len(want))
+	}
+
+	for i, w := range want {
+		row, err := src.Row(i)
+		if err != nil {
+			t.Fatalf("row %d: %v", i, err)
+		}
+		if !slices.Equal(row, w) {
+			t.Errorf("row %d = %q, want %q", i, row, w)
+		}
+	}
+}
+
+func TestIndexQuotedMultilineCSV(t *testing.T) {
+	// Enough rows to span several chunks and pages
+	content := strings.Builder{}
+	content.WriteString("id,note\n")
+	want := [][]string{}
+	for i := 0; i < 600; i++ {
+		note := fmt.Sprintf("line one of %d\nline two, \"quoted\"", i)
+		fmt.Fprintf(&content, "%d,\"%s\"\n", i, strings.ReplaceAll(note, `"`, `""`))
+		want = append(want, []string{fmt.Sprint(i), note})
+	}
+
+	src := indexFile(t, "notes.csv", content.String(), 100)
+	checkRows(t, src, want)
+}
+
+func TestIndexNoTrailingNewline(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    [][]string
+	}{
+		{"a.csv", "a,b\n1,2\n3,4", [][]string{{"1", "2"}, {"3", "4"}}},
+		{"a.tsv", "a\tb\n1\t2\n3\t4", [][]string{{"1", "2"}, {"3", "4"}}},
+		{"a.jsonl", "{\"a\":1,\"b\":2}\n{\"a\":3,\"b\":4}", [][]string{{"1", "2"}, {"3", "4"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, chunk := range []int{1, chunkRows} {
+				src := indexFile(t, tt.name, tt.content, chunk)
+				checkRows(t, src, tt.want)
+
+				rows := [][]string{}
+				err := src.Scan(func(_ int, row []string) bool {
+					rows = append(rows, slices.Clone(row))
+					return true
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !slices.EqualFunc(rows, tt.want, slices.Equal[[]string]) {
+					t.Errorf("chunk %d: scanned %q, want %q", chunk, rows, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexJSONLHeaderChanges(t *testing.T) {
+	// Each chunk is more than a page, with a new key in its first line
+	content := strings.Builder{}
+	want := [][]string{}
+	for i := 0; i < 900; i++ {
+		switch i {
+		case 300:
+			fmt.Fprintf(&content, "{\"a\":%d,\"b\":\"x\"}\n\n", i)
+			want = append(want, []string{fmt.Sprint(i), "x", ""})
+		case 600:
+			fmt.Fprintf(&content, "{\"c\":true}\n")
+			want = append(want, []string{"", "", "true"})
+		default:
+			fmt.Fprintf(&content, "{\"a\":%d}\n", i)
+			want = append(want, []string{fmt.Sprint(i), "", ""})
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, ix, err := openSource(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	fs := src.(*fileSource)
+	ix.chunk = 300
+
+	headers := [][]string{{"a"}, {"a", "b"}, {"a", "b", "c"}}
+	for i, header := range headers {
+		msg := ix.next()().(indexedMsg)
+		if msg.err != nil {
+			t.Fatal(msg.err)
+		}
+		fs.add(msg)
+
+		if !slices.Equal(fs.Header(), header) {
+			t.Fatalf("after chunk %d header = %q, want %q", i+1, fs.Header(), header)
+		}
+
+		// Rows read under an earlier header have to grow with it
+		row, err := fs.Row(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(row) != len(header) || row[0] != "0" {
+			t.Errorf("after chunk %d row 0 = %q", i+1, row)
+		}
+	}
+
+	checkRows(t, fs, want)
+}
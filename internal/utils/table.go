@@ -1,7 +1,12 @@
 package utils
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -10,132 +15,115 @@ var tableStyle = lipgloss.NewStyle().
 	BorderStyle(lipgloss.NormalBorder()).
 	BorderForeground(lipgloss.Color("240"))
 
-type tFocusMsg struct{}
+type inputMode int
+
+const (
+	inputNone inputMode = iota
+	inputOpen
+	inputFilter
+	inputExport
+	inputSignal
+)
+
+type datasetMsg struct {
+	path string
+	src  dataSource
+	ix   *indexer
+	err  error
+}
+
+type viewMsg struct {
+	src  dataSource
+	view []int
+	err  error
+}
 
-func tableFocusMsg() tea.Msg {
-	return tFocusMsg{}
+type exportedMsg struct {
+	src  dataSource
+	path string
+	rows int
+	err  error
+}
+
+type dataKeymap struct {
+	Open   key.Binding
+	Filter key.Binding
+	Export key.Binding
+	Left   key.Binding
+	Right  key.Binding
+	Sort   key.Binding
+}
+
+var dataKeys = dataKeymap{
+	Open: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open file"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "filter"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "export view"),
+	),
+	Left: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/h", "prev column"),
+	),
+	Right: key.NewBinding(
+		key.WithKeys("right", "l"),
+		key.WithHelp("→/l", "next column"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "sort by column"),
+	),
+}
+
+// dataModel shows a CSV, TSV or JSON lines file in a table that can be
+// sorted, filtered and exported. Rows are read from disk as they're
+// scrolled into view, the table only ever holds the visible ones.
+type dataModel struct {
+	keys    dataKeymap
+	nav     table.KeyMap
+	table   table.Model
+	input   textinput.Model
+	mode    inputMode
+	src     dataSource
+	widths  []int
+	view    []int // row positions, filtered and sorted, nil for every row
+	cursor  int   // position in the view
+	offset  int   // first position shown
+	column  int
+	sortCol int
+	desc    bool
+	filter  string
+	path    string
+	busy    string
+	focused bool
+	status  string
+	err     error
+}
+
+func newData() dataModel {
+	return dataModel{
+		keys:    dataKeys,
+		nav:     table.DefaultKeyMap(),
+		table:   newTable(),
+		input:   textinput.New(),
+		sortCol: -1,
+	}
 }
 
 func newTable() table.Model {
-	columns := []table.Column{
-		{Title: "Rank", Width: 4},
-		{Title: "City", Width: 10},
-		{Title: "Country", Width: 10},
-		{Title: "Population", Width: 10},
-	}
-
-	rows := []table.Row{
-		{"1", "Tokyo", "Japan", "37,274,000"},
-		{"2", "Delhi", "India", "32,065,760"},
-		{"3", "Shanghai", "China", "28,516,904"},
-		{"4", "Dhaka", "Bangladesh", "22,478,116"},
-		{"5", "São Paulo", "Brazil", "22,429,800"},
-		{"6", "Mexico City", "Mexico", "22,085,140"},
-		{"7", "Cairo", "Egypt", "21,750,020"},
-		{"8", "Beijing", "China", "21,333,332"},
-		{"9", "Mumbai", "India", "20,961,472"},
-		{"10", "Osaka", "Japan", "19,059,856"},
-		{"11", "Chongqing", "China", "16,874,740"},
-		{"12", "Karachi", "Pakistan", "16,839,950"},
-		{"13", "Istanbul", "Turkey", "15,636,243"},
-		{"14", "Kinshasa", "DR Congo", "15,628,085"},
-		{"15", "Lagos", "Nigeria", "15,387,639"},
-		{"16", "Buenos Aires", "Argentina", "15,369,919"},
-		{"17", "Kolkata", "India", "15,133,888"},
-		{"18", "Manila", "Philippines", "14,406,059"},
-		{"19", "Tianjin", "China", "14,011,828"},
-		{"20", "Guangzhou", "China", "13,964,637"},
-		{"21", "Rio De Janeiro", "Brazil", "13,634,274"},
-		{"22", "Lahore", "Pakistan", "13,541,764"},
-		{"23", "Bangalore", "India", "13,193,035"},
-		{"24", "Shenzhen", "China", "12,831,330"},
-		{"25", "Moscow", "Russia", "12,640,818"},
-		{"26", "Chennai", "India", "11,503,293"},
-		{"27", "Bogota", "Colombia", "11,344,312"},
-		{"28", "Paris", "France", "11,142,303"},
-		{"29", "Jakarta", "Indonesia", "11,074,811"},
-		{"30", "Lima", "Peru", "11,044,607"},
-		{"31", "Bangkok", "Thailand", "10,899,698"},
-		{"32", "Hyderabad", "India", "10,534,418"},
-		{"33", "Seoul", "South Korea", "9,975,709"},
-		{"34", "Nagoya", "Japan", "9,571,596"},
-		{"35", "London", "United Kingdom", "9,540,576"},
-		{"36", "Chengdu", "China", "9,478,521"},
-		{"37", "Nanjing", "China", "9,429,381"},
-		{"38", "Tehran", "Iran", "9,381,546"},
-		{"39", "Ho Chi Minh City", "Vietnam", "9,077,158"},
-		{"40", "Luanda", "Angola", "8,952,496"},
-		{"41", "Wuhan", "China", "8,591,611"},
-		{"42", "Xi An Shaanxi", "China", "8,537,646"},
-		{"43", "Ahmedabad", "India", "8,450,228"},
-		{"44", "Kuala Lumpur", "Malaysia", "8,419,566"},
-		{"45", "New York City", "United States", "8,177,020"},
-		{"46", "Hangzhou", "China", "8,044,878"},
-		{"47", "Surat", "India", "7,784,276"},
-		{"48", "Suzhou", "China", "7,764,499"},
-		{"49", "Hong Kong", "Hong Kong", "7,643,256"},
-		{"50", "Riyadh", "Saudi Arabia", "7,538,200"},
-		{"51", "Shenyang", "China", "7,527,975"},
-		{"52", "Baghdad", "Iraq", "7,511,920"},
-		{"53", "Dongguan", "China", "7,511,851"},
-		{"54", "Foshan", "China", "7,497,263"},
-		{"55", "Dar Es Salaam", "Tanzania", "7,404,689"},
-		{"56", "Pune", "India", "6,987,077"},
-		{"57", "Santiago", "Chile", "6,856,939"},
-		{"58", "Madrid", "Spain", "6,713,557"},
-		{"59", "Haerbin", "China", "6,665,951"},
-		{"60", "Toronto", "Canada", "6,312,974"},
-		{"61", "Belo Horizonte", "Brazil", "6,194,292"},
-		{"62", "Khartoum", "Sudan", "6,160,327"},
-		{"63", "Johannesburg", "South Africa", "6,065,354"},
-		{"64", "Singapore", "Singapore", "6,039,577"},
-		{"65", "Dalian", "China", "5,930,140"},
-		{"66", "Qingdao", "China", "5,865,232"},
-		{"67", "Zhengzhou", "China", "5,690,312"},
-		{"68", "Ji Nan Shandong", "China", "5,663,015"},
-		{"69", "Barcelona", "Spain", "5,658,472"},
-		{"70", "Saint Petersburg", "Russia", "5,535,556"},
-		{"71", "Abidjan", "Ivory Coast", "5,515,790"},
-		{"72", "Yangon", "Myanmar", "5,514,454"},
-		{"73", "Fukuoka", "Japan", "5,502,591"},
-		{"74", "Alexandria", "Egypt", "5,483,605"},
-		{"75", "Guadalajara", "Mexico", "5,339,583"},
-		{"76", "Ankara", "Turkey", "5,309,690"},
-		{"77", "Chittagong", "Bangladesh", "5,252,842"},
-		{"78", "Addis Ababa", "Ethiopia", "5,227,794"},
-		{"79", "Melbourne", "Australia", "5,150,766"},
-		{"80", "Nairobi", "Kenya", "5,118,844"},
-		{"81", "Hanoi", "Vietnam", "5,067,352"},
-		{"82", "Sydney", "Australia", "5,056,571"},
-		{"83", "Monterrey", "Mexico", "5,036,535"},
-		{"84", "Changsha", "China", "4,809,887"},
-		{"85", "Brasilia", "Brazil", "4,803,877"},
-		{"86", "Cape Town", "South Africa", "4,800,954"},
-		{"87", "Jiddah", "Saudi Arabia", "4,780,740"},
-		{"88", "Urumqi", "China", "4,710,203"},
-		{"89", "Kunming", "China", "4,657,381"},
-		{"90", "Changchun", "China", "4,616,002"},
-		{"91", "Hefei", "China", "4,496,456"},
-		{"92", "Shantou", "China", "4,490,411"},
-		{"93", "Xinbei", "Taiwan", "4,470,672"},
-		{"94", "Kabul", "Afghanistan", "4,457,882"},
-		{"95", "Ningbo", "China", "4,405,292"},
-		{"96", "Tel Aviv", "Israel", "4,343,584"},
-		{"97", "Yaounde", "Cameroon", "4,336,670"},
-		{"98", "Rome", "Italy", "4,297,877"},
-		{"99", "Shijiazhuang", "China", "4,285,135"},
-		{"100", "Montreal", "Canada", "4,276,526"},
-	}
-
-	t := table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
-		table.WithFocused(true),
-		table.WithHeight(7),
+	return table.New(
+		table.WithHeight(10),
+		table.WithStyles(tableStyles()),
 	)
+}
 
-	t.SetCursor(0)
-
+func tableStyles() table.Styles {
 	s := table.DefaultStyles()
 	s.Header = s.Header.
 		BorderStyle(lipgloss.NormalBorder()).
@@ -146,7 +134,407 @@ func newTable() table.Model {
 		Foreground(lipgloss.Color("229")).
 		Background(lipgloss.Color("57")).
 		Bold(false)
-	t.SetStyles(s)
 
-	return t
+	return s
+}
+
+func (m *dataModel) Focus() tea.Cmd {
+	m.focused = true
+	m.table.Focus()
+
+	if m.path == "" {
+		return m.prompt(inputOpen, "Open: ", "")
+	}
+
+	return nil
+}
+
+func (m *dataModel) Blur() {
+	m.focused = false
+	m.mode = inputNone
+	m.input.Blur()
+	m.table.Blur()
+}
+
+func (m dataModel) Focused() bool {
+	return m.focused
+}
+
+func (m dataModel) Typing() bool {
+	return m.mode != inputNone
+}
+
+func (m *dataModel) SetSize(width, height int) {
+	m.table.SetHeight(max(height-6, 3))
+	m.input.Width = max(width-12, 10)
+	m.fill()
+}
+
+func (m *dataModel) prompt(mode inputMode, prompt, value string) tea.Cmd {
+	m.mode = mode
+	m.input.Prompt = prompt
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+
+	return m.input.Focus()
+}
+
+func (m dataModel) Update(msg tea.Msg) (dataModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case datasetMsg:
+		m.err = msg.err
+		if msg.err != nil {
+			return m, nil
+		}
+
+		if m.src != nil {
+			m.src.Close()
+		}
+		m.path, m.src = msg.path, msg.src
+		m.view, m.cursor, m.offset = nil, 0, 0
+		m.column, m.sortCol, m.desc, m.filter = 0, -1, false, ""
+		m.widths = widths(m.src, 30)
+		m.setColumns()
+		m.fill()
+
+		if msg.ix != nil {
+			m.busy = "Indexing..."
+			return m, msg.ix.next()
+		}
+
+		m.busy = ""
+		m.status = fmt.Sprintf("%d rows, %d columns", m.src.Len(), len(m.src.Header()))
+		return m, nil
+
+	case indexedMsg:
+		src, ok := m.src.(*fileSource)
+		if !ok || src != msg.ix.src {
+			// A different file was opened since
+			msg.ix.f.Close()
+			return m, nil
+		}
+
+		first := src.Len() == 0
+		src.add(msg)
+		if first || len(msg.header) > 0 {
+			m.widths = widths(src, 30)
+			m.setColumns()
+		}
+		m.fill()
+
+		switch {
+		case msg.err != nil:
+			m.busy, m.err = "", msg.err
+		case msg.done:
+			m.busy = ""
+			m.status = fmt.Sprintf("%d rows, %d columns", src.Len(), len(src.header))
+		default:
+			m.busy = fmt.Sprintf("Indexing... %d rows", src.Len())
+			return m, msg.ix.next()
+		}
+		return m, nil
+
+	case viewMsg:
+		if msg.src != m.src {
+			return m, nil
+		}
+
+		m.busy, m.err = "", msg.err
+		if msg.err == nil {
+			m.view, m.cursor, m.offset = msg.view, 0, 0
+			m.fill()
+		}
+		return m, nil
+
+	case exportedMsg:
+		// Another file may be indexing by now, it's still busy
+		if msg.src != m.src {
+			return m, nil
+		}
+
+		m.busy, m.err = "", msg.err
+		if msg.err == nil {
+			m.status = fmt.Sprintf("Exported %d rows to %s", msg.rows, msg.path)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.mode != inputNone {
+			return m.updateInput(msg)
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Open):
+			return m, m.prompt(inputOpen, "Open: ", m.path)
+		case key.Matches(msg, m.keys.Filter):
+			if m.ready() {
+				return m, m.prompt(inputFilter, "Filter: ", m.filter)
+			}
+		case key.Matches(msg, m.keys.Export):
+			if m.ready() {
+				return m, m.prompt(inputExport, "Export to: ", "")
+			}
+		case key.Matches(msg, m.keys.Left):
+			if m.column > 0 {
+				m.column--
+				m.setColumns()
+			}
+		case key.Matches(msg, m.keys.Right):
+			if m.src != nil && m.column < len(m.src.Header())-1 {
+				m.column++
+				m.setColumns()
+			}
+		case key.Matches(msg, m.keys.Sort):
+			if m.ready() {
+				// First press sorts ascending, the next one flips it
+				m.desc = m.sortCol == m.column && !m.desc
+				m.sortCol = m.column
+				m.setColumns()
+				return m, m.apply()
+			}
+		default:
+			m.move(msg)
+		}
+	}
+
+	return m, nil
+}
+
+// move handles the table's navigation keys over the whole view.
+func (m *dataModel) move(msg tea.KeyMsg) {
+	h := m.table.Height()
+
+	switch {
+	case key.Matches(msg, m.nav.LineUp):
+		m.cursor--
+	case key.Matches(msg, m.nav.LineDown):
+		m.cursor++
+	case key.Matches(msg, m.nav.PageUp):
+		m.cursor -= h
+	case key.Matches(msg, m.nav.PageDown):
+		m.cursor += h
+	case key.Matches(msg, m.nav.HalfPageUp):
+		m.cursor -= h / 2
+	case key.Matches(msg, m.nav.HalfPageDown):
+		m.cursor += h / 2
+	case key.Matches(msg, m.nav.GotoTop):
+		m.cursor = 0
+	case key.Matches(msg, m.nav.GotoBottom):
+		m.cursor = m.count() - 1
+	default:
+		return
+	}
+
+	m.fill()
+}
+
+// ready reports whether the whole file is available to filter, sort and
+// export.
+func (m dataModel) ready() bool {
+	return m.src != nil && m.busy == ""
+}
+
+func (m dataModel) updateInput(msg tea.KeyMsg) (dataModel, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = inputNone
+		m.input.Blur()
+		return m, nil
+
+	case tea.KeyEnter:
+		value := strings.TrimSpace(m.input.Value())
+		mode := m.mode
+		m.mode = inputNone
+		m.input.Blur()
+
+		switch mode {
+		case inputOpen:
+			if value != "" {
+				return m, openDataset(expandHome(value))
+			}
+		case inputFilter:
+			m.filter = value
+			return m, m.apply()
+		case inputExport:
+			if value != "" {
+				m.busy = "Exporting..."
+				return m, exportDataset(m.src, m.view, expandHome(value))
+			}
+		}
+
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+
+	return m, cmd
+}
+
+// apply filters and sorts the rows in the background.
+func (m *dataModel) apply() tea.Cmd {
+	terms, err := parseFilter(m.src.Header(), m.filter)
+	m.err = err
+	if err != nil {
+		return nil
+	}
+
+	if len(terms) == 0 && m.sortCol < 0 {
+		m.view, m.cursor, m.offset = nil, 0, 0
+		m.fill()
+		return nil
+	}
+
+	m.busy = "Sorting..."
+	if len(terms) > 0 {
+		m.busy = "Filtering..."
+	}
+
+	return applyView(m.src, terms, m.sortCol, m.desc)
+}
+
+// count is the number of rows in the view.
+func (m dataModel) count() int {
+	if m.view != nil {
+		return len(m.view)
+	}
+	if m.src != nil {
+		return m.src.Len()
+	}
+
+	return 0
+}
+
+// fill keeps the cursor in view and hands the visible rows to the table.
+func (m *dataModel) fill() {
+	if m.src == nil {
+		return
+	}
+
+	n, h := m.count(), m.table.Height()
+	m.cursor = max(min(m.cursor, n-1), 0)
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+	m.offset = max(min(m.offset, n-h), 0)
+
+	rows := make([]table.Row, 0, h)
+	for p := m.offset; p < min(n, m.offset+h); p++ {
+		i := p
+		if m.view != nil {
+			i = m.view[p]
+		}
+
+		row, err := m.src.Row(i)
+		if err != nil {
+			m.err = err
+			break
+		}
+		rows = append(rows, row)
+	}
+
+	m.table.SetRows(rows)
+	m.table.SetCursor(m.cursor - m.offset)
+}
+
+// setColumns marks the selected and sorted columns in the headers.
+func (m *dataModel) setColumns() {
+	header := m.src.Header()
+	cols := make([]table.Column, len(header))
+	for i, h := range header {
+		if i == m.sortCol && m.desc {
+			h += " ▼"
+		} else if i == m.sortCol {
+			h += " ▲"
+		}
+		if i == m.column {
+			h = "[" + h + "]"
+		}
+
+		cols[i] = table.Column{Title: h, Width: m.widths[i]}
+	}
+
+	// Rows may not fit the new columns
+	m.table.SetRows(nil)
+	m.table.SetColumns(cols)
+	m.fill()
+}
+
+func (m dataModel) View() string {
+	s := []string{}
+
+	if m.src != nil {
+		s = append(s, tableStyle.Render(m.table.View()))
+		info := fmt.Sprintf("%s · row %d of %d", m.path, min(m.cursor+1, m.count()), m.count())
+		if m.view != nil {
+			info += fmt.Sprintf(" (%d total)", m.src.Len())
+		}
+		if m.filter != "" {
+			info += " · filter: " + m.filter
+		}
+		s = append(s, mutedStyle.Render(info))
+	} else {
+		s = append(s, "Open a CSV, TSV or JSON lines file to get started")
+	}
+
+	if m.mode != inputNone {
+		s = append(s, m.input.View())
+	} else {
+		s = append(s, mutedStyle.Render("o open · / filter (text, col=val, col>n, col~text) · ←/→ column · s sort · x export · esc back"))
+	}
+
+	if m.err != nil {
+		s = append(s, errStyle.Render(m.err.Error()))
+	} else if m.busy != "" {
+		s = append(s, m.busy)
+	} else if m.status != "" {
+		s = append(s, m.status)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, s...)
+}
+
+// Commands
+
+func openDataset(path string) tea.Cmd {
+	return func() tea.Msg {
+		src, ix, err := openSource(path)
+		return datasetMsg{path: path, src: src, ix: ix, err: err}
+	}
+}
+
+func applyView(src dataSource, terms []filterTerm, col int, desc bool) tea.Cmd {
+	return func() tea.Msg {
+		var view []int
+		var err error
+
+		if len(terms) > 0 {
+			view, err = filterRows(src, terms)
+		} else {
+			view = make([]int, src.Len())
+			for i := range view {
+				view[i] = i
+			}
+		}
+
+		if err == nil && col >= 0 {
+			err = sortRows(src, view, col, desc)
+		}
+
+		return viewMsg{src: src, view: view, err: err}
+	}
+}
+
+func exportDataset(src dataSource, view []int, path string) tea.Cmd {
+	return func() tea.Msg {
+		rows := src.Len()
+		if view != nil {
+			rows = len(view)
+		}
+
+		return exportedMsg{src: src, path: path, rows: rows, err: export(src, path, view)}
+	}
 }
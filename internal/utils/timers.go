@@ -0,0 +1,459 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-live/internal/common"
+)
+
+// clock is a countdown timer or a stopwatch. Time is measured from the wall
+// clock rather than counted in ticks, so it stays right however busy the UI
+// is.
+type clock struct {
+	name      string
+	stopwatch bool
+	duration  time.Duration   // timers only
+	elapsed   time.Duration   // banked while paused
+	started   time.Time       // zero while paused
+	laps      []time.Duration // elapsed at each lap
+	alarm     atomic.Int64    // bumped to call off the pending alarm
+}
+
+func (c *clock) running() bool {
+	return !c.started.IsZero()
+}
+
+func (c *clock) total(now time.Time) time.Duration {
+	if c.running() {
+		return c.elapsed + now.Sub(c.started)
+	}
+
+	return c.elapsed
+}
+
+func (c *clock) remaining(now time.Time) time.Duration {
+	return max(c.duration-c.total(now), 0)
+}
+
+func (c *clock) expired(now time.Time) bool {
+	return !c.stopwatch && c.remaining(now) == 0
+}
+
+// start returns the alarm for a timer. Its message goes to root, so it rings
+// whichever screen is showing, the redraw tick only runs while the timers
+// are on screen.
+func (c *clock) start(now time.Time) tea.Cmd {
+	if c.running() || c.expired(now) {
+		return nil
+	}
+
+	c.started = now
+	if c.stopwatch {
+		return nil
+	}
+
+	alarm, name, d := c.alarm.Add(1), c.name, c.duration
+	return tea.Tick(c.remaining(now), func(time.Time) tea.Msg {
+		if c.alarm.Load() != alarm {
+			return nil
+		}
+		return common.NotifyMsg{Title: "go-live", Body: fmt.Sprintf("%s finished (%s)", name, d)}
+	})
+}
+
+// deadline is when a running timer expires or expired.
+func (c *clock) deadline() time.Time {
+	return c.started.Add(c.duration - c.elapsed)
+}
+
+func (c *clock) pause(now time.Time) {
+	// An expired timer keeps its deadline until it's reset
+	if !c.running() || c.expired(now) {
+		return
+	}
+
+	c.elapsed = c.total(now)
+	c.started = time.Time{}
+	c.stop()
+}
+
+func (c *clock) stop() {
+	c.alarm.Add(1)
+}
+
+func (c *clock) reset(now time.Time) tea.Cmd {
+	c.stop()
+	c.started, c.elapsed, c.laps = time.Time{}, 0, nil
+
+	// Timers start over, stopwatches wait for space
+	if !c.stopwatch {
+		return c.start(now)
+	}
+
+	return nil
+}
+
+type timersTickMsg struct {
+	id int
+}
+
+type timersKeymap struct {
+	New       key.Binding
+	Stopwatch key.Binding
+	Toggle    key.Binding
+	Lap       key.Binding
+	Reset     key.Binding
+	Delete    key.Binding
+}
+
+var timersKeys = timersKeymap{
+	New: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new timer"),
+	),
+	Stopwatch: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "new stopwatch"),
+	),
+	Toggle: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "pause/resume"),
+	),
+	Lap: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "lap"),
+	),
+	Reset: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "reset"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("x", "delete"),
+		key.WithHelp("x", "delete"),
+	),
+}
+
+// timersModel runs any number of named countdown timers and stopwatches at
+// once. Timers ring the bell and send a desktop notification when they
+// expire.
+type timersModel struct {
+	keys    timersKeymap
+	nav     common.Keymap
+	input   textinput.Model
+	typing  bool
+	clocks  []*clock
+	cursor  int
+	focused bool
+	tick    int
+	err     error
+}
+
+func newTimers() timersModel {
+	input := textinput.New()
+	input.Prompt = "New timer: "
+	input.Placeholder = "tea 3m30s · 25 (minutes) · 1:30:00"
+	input.Width = 40
+
+	return timersModel{keys: timersKeys, nav: common.Keys, input: input}
+}
+
+func (m *timersModel) Focus() tea.Cmd {
+	m.focused = true
+	m.tick++
+
+	if len(m.clocks) == 0 {
+		return tea.Batch(m.prompt(), timersTick(m.tick))
+	}
+
+	return timersTick(m.tick)
+}
+
+func (m *timersModel) Blur() {
+	m.focused = false
+	m.typing = false
+	m.input.Blur()
+}
+
+func (m timersModel) Focused() bool {
+	return m.focused
+}
+
+func (m timersModel) Typing() bool {
+	return m.typing
+}
+
+func (m *timersModel) prompt() tea.Cmd {
+	m.typing = true
+	m.input.SetValue("")
+
+	return m.input.Focus()
+}
+
+func (m timersModel) Update(msg tea.Msg) (timersModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case timersTickMsg:
+		if !m.focused || msg.id != m.tick {
+			return m, nil
+		}
+		return m, timersTick(m.tick)
+
+	case tea.KeyMsg:
+		if m.typing {
+			return m.updateInput(msg)
+		}
+
+		now := time.Now()
+		var cmd tea.Cmd
+		var c *clock
+		if m.cursor < len(m.clocks) {
+			c = m.clocks[m.cursor]
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.New):
+			return m, m.prompt()
+		case key.Matches(msg, m.keys.Stopwatch):
+			c := &clock{name: fmt.Sprintf("Stopwatch %d", len(m.clocks)+1), stopwatch: true}
+			cmd = c.start(now)
+			m.clocks = append(m.clocks, c)
+			m.cursor = len(m.clocks) - 1
+		case key.Matches(msg, m.nav.Up):
+			m.cursor = max(m.cursor-1, 0)
+		case key.Matches(msg, m.nav.Down):
+			m.cursor = min(m.cursor+1, max(len(m.clocks)-1, 0))
+		case c == nil:
+		case key.Matches(msg, m.keys.Toggle):
+			if c.expired(now) {
+				cmd = c.reset(now)
+			} else if c.running() {
+				c.pause(now)
+			} else {
+				cmd = c.start(now)
+			}
+		case key.Matches(msg, m.keys.Lap):
+			if c.stopwatch && c.running() {
+				c.laps = append(c.laps, c.total(now))
+			}
+		case key.Matches(msg, m.keys.Reset):
+			cmd = c.reset(now)
+		case key.Matches(msg, m.keys.Delete):
+			c.stop()
+			m.clocks = append(m.clocks[:m.cursor], m.clocks[m.cursor+1:]...)
+			m.cursor = min(m.cursor, max(len(m.clocks)-1, 0))
+		}
+
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+func (m timersModel) updateInput(msg tea.KeyMsg) (timersModel, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.typing = false
+		m.input.Blur()
+		return m, nil
+
+	case tea.KeyEnter:
+		name, d, err := parseTimer(m.input.Value())
+		m.err = err
+		if err != nil {
+			return m, nil
+		}
+
+		if name == "" {
+			name = fmt.Sprintf("Timer %d", len(m.clocks)+1)
+		}
+
+		c := &clock{name: name, duration: d}
+		cmd := c.start(time.Now())
+		m.clocks = append(m.clocks, c)
+		m.cursor = len(m.clocks) - 1
+		m.typing = false
+		m.input.Blur()
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+
+	return m, cmd
+}
+
+// maxTimer is the longest timer, well short of what time.Duration can hold.
+const maxTimer = 30 * 24 * time.Hour
+
+// parseTimer reads an optional name followed by a duration, which can be
+// Go style (3m30s), a clock (1:30:00) or a bare number of minutes.
+func parseTimer(s string) (string, time.Duration, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return "", 0, errors.New("enter a duration, e.g. 5m or tea 3m30s")
+	}
+
+	name := strings.Join(fields[:len(fields)-1], " ")
+	last := fields[len(fields)-1]
+
+	d, err := timerDuration(last)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return name, d, nil
+}
+
+func timerDuration(s string) (time.Duration, error) {
+	tooLong := fmt.Errorf("%s is too long, timers run for up to %d days", s, maxTimer/(24*time.Hour))
+	d := time.Duration(0)
+
+	if pd, err := time.ParseDuration(s); err == nil && pd > 0 {
+		d = pd
+	} else if n, err := strconv.ParseFloat(s, 64); err == nil && n > 0 {
+		// Checked before converting, which would overflow
+		if n > maxTimer.Minutes() {
+			return 0, tooLong
+		}
+		d = time.Duration(n * float64(time.Minute))
+	} else if parts := strings.Split(s, ":"); len(parts) <= 3 {
+		for _, p := range parts {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("can't read %q as a duration", s)
+			}
+			if n > int(maxTimer/time.Second) || d > maxTimer {
+				return 0, tooLong
+			}
+			d = d*60 + time.Duration(n)*time.Second
+		}
+	}
+
+	switch {
+	case d <= 0:
+		return 0, fmt.Errorf("can't read %q as a duration", s)
+	case d > maxTimer:
+		return 0, tooLong
+	}
+
+	return d, nil
+}
+
+func (m timersModel) View() string {
+	now := time.Now()
+	s := []string{}
+
+	if m.typing {
+		s = append(s, m.input.View(), "")
+	}
+
+	if len(m.clocks) == 0 {
+		s = append(s, "No timers yet")
+	} else {
+		s = append(s, mutedStyle.Render(fmt.Sprintf("   %-20s %-10s %11s  %s", "Name", "Kind", "Time", "State")))
+	}
+
+	for i, c := range m.clocks {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "->"
+		}
+
+		kind, shown := "timer", formatClock(c.remaining(now).Round(time.Second))
+		if c.stopwatch {
+			kind, shown = "stopwatch", formatLap(c.total(now))
+		}
+
+		state := mutedStyle.Render("paused")
+		switch {
+		case c.expired(now):
+			state = warnStyle.Render("done at " + c.deadline().Format("15:04:05"))
+		case c.running() && c.stopwatch:
+			state = upStyle.Render("running")
+		case c.running():
+			state = upStyle.Render("running") + mutedStyle.Render(" · ends "+c.deadline().Format("15:04:05"))
+		}
+		if len(c.laps) > 0 {
+			state += mutedStyle.Render(fmt.Sprintf(" · %d laps", len(c.laps)))
+		}
+
+		row := fmt.Sprintf("%s %-20s %-10s %11s  ", cursor, truncate(c.name, 20), kind, shown)
+		if i == m.cursor {
+			row = activeStyle.Render(row)
+		}
+		s = append(s, row+state)
+	}
+
+	if m.cursor < len(m.clocks) && len(m.clocks[m.cursor].laps) > 0 {
+		s = append(s, "", mutedStyle.Render(fmt.Sprintf("%-6s %11s %11s", "Lap", "Split", "Total")))
+
+		laps := m.clocks[m.cursor].laps
+		for i := len(laps) - 1; i >= 0 && i >= len(laps)-10; i-- {
+			split := laps[i]
+			if i > 0 {
+				split -= laps[i-1]
+			}
+			s = append(s, fmt.Sprintf("%-6d %11s %11s", i+1, formatLap(split), formatLap(laps[i])))
+		}
+	}
+
+	if m.err != nil {
+		s = append(s, "", errStyle.Render(m.err.Error()))
+	}
+
+	s = append(s, "", mutedStyle.Render("n new timer · w stopwatch · space pause/resume · l lap · r reset · x delete · esc back"))
+
+	return lipgloss.JoinVertical(lipgloss.Left, s...)
+}
+
+// Summary lists what's running for the Utils menu. It only shows times that
+// don't go stale, as the menu isn't redrawn every second.
+func (m timersModel) Summary() []string {
+	now := time.Now()
+	s := []string{}
+
+	for _, c := range m.clocks {
+		switch {
+		case c.expired(now):
+			s = append(s, warnStyle.Render(fmt.Sprintf("%s finished at %s", c.name, c.deadline().Format("15:04:05"))))
+		case c.running() && c.stopwatch:
+			s = append(s, fmt.Sprintf("%s running since %s", c.name, c.started.Add(-c.elapsed).Format("15:04:05")))
+		case c.running():
+			s = append(s, fmt.Sprintf("%s ends at %s", c.name, c.deadline().Format("15:04:05")))
+		}
+	}
+
+	return s
+}
+
+// formatClock shows d as 1:02:03 or 2:03.
+func formatClock(d time.Duration) string {
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// formatLap is formatClock with tenths of a second.
+func formatLap(d time.Duration) string {
+	return fmt.Sprintf("%s.%d", formatClock(d), int(d/(100*time.Millisecond))%10)
+}
+
+// Commands
+
+func timersTick(id int) tea.Cmd {
+	return tea.Tick(100*time.Millisecond, func(time.Time) tea.Msg {
+		return timersTickMsg{id: id}
+	})
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimer(t *testing.T) {
+	tests := []struct {
+		input string
+		name  string
+		d     time.Duration
+		err   string
+	}{
+		{"5m", "", 5 * time.Minute, ""},
+		{"tea 3m30s", "tea", 3*time.Minute + 30*time.Second, ""},
+		{"  long  name here 10s ", "long name here", 10 * time.Second, ""},
+		{"25", "", 25 * time.Minute, ""},
+		{"1.5", "", 90 * time.Second, ""},
+		{"2:03", "", 2*time.Minute + 3*time.Second, ""},
+		{"1:30:00", "", 90 * time.Minute, ""},
+		{"45", "", 45 * time.Minute, ""},
+		{"720h", "", maxTimer, ""},
+		{"720:00:00", "", maxTimer, ""},
+
+		{"", "", 0, "enter a duration"},
+		{"0", "", 0, "can't read"},
+		{"0:00", "", 0, "can't read"},
+		{"-5m", "", 0, "can't read"},
+		{"abc", "", 0, "can't read"},
+		{"1:2:3:4", "", 0, "can't read"},
+		{"1:-2", "", 0, "can't read"},
+		{"NaN", "", 0, "can't read"},
+		{"1e-12", "", 0, "can't read"},
+		{"720h1s", "", 0, "too long"},
+		{"1e10", "", 0, "too long"},
+		{"Inf", "", 0, "too long"},
+		{"43201", "", 0, "too long"},
+		{"720:00:01", "", 0, "too long"},
+		{"99999999999:00", "", 0, "too long"},
+		{"5000000:59:59", "", 0, "too long"},
+		{"1:5000000:00", "", 0, "too long"},
+		{"9223372036854775807:00:00", "", 0, "too long"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			name, d, err := parseTimer(tt.input)
+
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("parseTimer(%q) = %q, %s, %v, want an error containing %q", tt.input, name, d, err, tt.err)
+				}
+				return
+			}
+
+			if err != nil || name != tt.name || d != tt.d {
+				t.Errorf("parseTimer(%q) = %q, %s, %v, want %q, %s", tt.input, name, d, err, tt.name, tt.d)
+			}
+		})
+	}
+}
@@ -4,13 +4,9 @@ import (
 	"fmt"
 	"go-live/internal/common"
 	"log"
-	"net/http"
-	"time"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
-	"github.com/charmbracelet/bubbles/progress"
-	"github.com/charmbracelet/bubbles/table"
-	"github.com/charmbracelet/bubbles/timer"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -28,8 +24,12 @@ type choiceID int
 const (
 	idTable choiceID = iota
 	idTimer
-	idPing
+	idProbe
+	idMonitor
+	idCerts
+	idPorts
 	idProgress
+	idLogs
 )
 
 var (
@@ -55,18 +55,19 @@ type choice struct {
 	key   choiceID
 }
 
-type tickMsg time.Time
-
 type UtilsModel struct {
-	choices       []choice
-	state         map[choice]bool
-	cursor        int
-	timerSpent    bool
-	timer         timer.Model
-	progressSpent bool
-	progress      progress.Model
-	table         table.Model
-	keys          common.Keymap
+	choices []choice
+	state   map[choice]bool
+	cursor  int
+	timers  timersModel
+	script  scriptModel
+	data    dataModel
+	probe   probeModel
+	monitor monitorModel
+	certs   certsModel
+	ports   portsModel
+	logs    logsModel
+	keys    common.Keymap
 }
 
 func NewModel() UtilsModel {
@@ -74,18 +75,23 @@ func NewModel() UtilsModel {
 		keys: common.Keys,
 		choices: []choice{
 			idTable:    {"Table", idTable},
-			idTimer:    {"Timer", idTimer},
-			idPing:     {"Ping Google", idPing},
-			idProgress: {"Progress", idProgress},
+			idTimer:    {"Timers", idTimer},
+			idProbe:    {"HTTP Probe", idProbe},
+			idMonitor:  {"Uptime Monitor", idMonitor},
+			idCerts:    {"TLS Certificates", idCerts},
+			idPorts:    {"Listening Ports", idPorts},
+			idProgress: {"Run Script", idProgress},
+			idLogs:     {"Logs", idLogs},
 		},
-		state:      map[choice]bool{},
-		timerSpent: false,
-		timer:      timer.NewWithInterval(5*time.Second, time.Millisecond),
-
-		progressSpent: false,
-		progress:      progress.New(progress.WithScaledGradient("#6A6094", "#FF6E81")),
-		// progress: progress.New(progress.WithDefaultGradient()),
-		table: newTable(),
+		state:   map[choice]bool{},
+		timers:  newTimers(),
+		script:  newScript(),
+		data:    newData(),
+		probe:   newProbe(),
+		monitor: newMonitor(),
+		certs:   newCerts(),
+		ports:   newPorts(),
+		logs:    newLogs(),
 	}
 }
 
@@ -98,21 +104,150 @@ func (m UtilsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// current choice, if it's active and focused/focusable
 
 	// log.Println("utils.Update msg:", msg)
-	// log.Println("utils.m.table.focus msg:", msg, m.table.Focused())
-	if m.table.Focused() {
-		switch msg := msg.(type) {
-		case tea.KeyMsg:
-			switch {
-			case key.Matches(msg, m.keys.Back), key.Matches(msg, m.keys.Blur):
-				m.setState(idTable, false)
-				m.table.Blur()
-			case key.Matches(msg, m.keys.Select):
-				log.Printf("Let's go to %s!", m.table.SelectedRow()[1])
-			}
+	// log.Println("utils.m.data.focus msg:", msg, m.data.Focused())
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		height := msg.Height - lipgloss.Height(m.headerView()) - lipgloss.Height(m.footerView())
+		m.logs.SetSize(msg.Width, height)
+		m.data.SetSize(msg.Width, height)
+		m.script.SetSize(msg.Width, height)
+		m.ports.SetSize(msg.Width, height)
+	case logFilesMsg, logLoadedMsg:
+		var cmd tea.Cmd
+		m.logs, cmd = m.logs.Update(msg)
+		return m, cmd
+	case probeResultsMsg, probeHistoryMsg:
+		var cmd tea.Cmd
+		m.probe, cmd = m.probe.Update(msg)
+		return m, cmd
+	case monitorLoadedMsg, monitorTickMsg, monitorEventMsg:
+		var cmd tea.Cmd
+		m.monitor, cmd = m.monitor.Update(msg)
+		return m, cmd
+	case scriptStartedMsg, scriptOutputMsg:
+		var cmd tea.Cmd
+		m.script, cmd = m.script.Update(msg)
+		return m, cmd
+	case timersTickMsg:
+		var cmd tea.Cmd
+		m.timers, cmd = m.timers.Update(msg)
+		return m, cmd
+	case certsMsg:
+		var cmd tea.Cmd
+		m.certs, cmd = m.certs.Update(msg)
+		return m, cmd
+	case portsMsg, signalledMsg:
+		var cmd tea.Cmd
+		m.ports, cmd = m.ports.Update(msg)
+		return m, cmd
+	case datasetMsg, indexedMsg, viewMsg, exportedMsg:
+		var cmd tea.Cmd
+		m.data, cmd = m.data.Update(msg)
+		return m, cmd
+	}
+
+	if m.script.Focused() {
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, m.keys.Back) &&
+			!m.script.running && !m.script.log.Typing() {
+			m.setState(idProgress, false)
+			m.script.Blur()
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		m.script, cmd = m.script.Update(msg)
+
+		return m, cmd
+	}
+
+	if m.timers.Focused() {
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, m.keys.Back) && !m.timers.Typing() {
+			m.setState(idTimer, false)
+			m.timers.Blur()
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		m.timers, cmd = m.timers.Update(msg)
+
+		return m, cmd
+	}
+
+	if m.ports.Focused() {
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, m.keys.Back) && !m.ports.Typing() {
+			m.setState(idPorts, false)
+			m.ports.Blur()
+			return m, nil
 		}
 
 		var cmd tea.Cmd
-		m.table, cmd = m.table.Update(msg)
+		m.ports, cmd = m.ports.Update(msg)
+
+		return m, cmd
+	}
+
+	if m.certs.Focused() {
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, m.keys.Back) {
+			m.setState(idCerts, false)
+			m.certs.Blur()
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		m.certs, cmd = m.certs.Update(msg)
+
+		return m, cmd
+	}
+
+	if m.monitor.Focused() {
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, m.keys.Back) {
+			m.setState(idMonitor, false)
+			m.monitor.Blur()
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		m.monitor, cmd = m.monitor.Update(msg)
+
+		return m, cmd
+	}
+
+	if m.probe.Focused() {
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, m.keys.Back) && !m.probe.running {
+			m.setState(idProbe, false)
+			m.probe.Blur()
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		m.probe, cmd = m.probe.Update(msg)
+
+		return m, cmd
+	}
+
+	if m.logs.Focused() {
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, m.keys.Back) && !m.logs.viewing {
+			m.setState(idLogs, false)
+			m.logs.Blur()
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		m.logs, cmd = m.logs.Update(msg)
+
+		return m, cmd
+	}
+
+	if m.data.Focused() {
+		if msg, ok := msg.(tea.KeyMsg); ok && !m.data.Typing() &&
+			(key.Matches(msg, m.keys.Back) || key.Matches(msg, m.keys.Blur)) {
+			m.setState(idTable, false)
+			m.data.Blur()
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		m.data, cmd = m.data.Update(msg)
 
 		return m, cmd
 	}
@@ -139,41 +274,6 @@ func (m UtilsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.toggleCurrentState()
 			return m.handleCurrentChoice(msg)
 		}
-
-	// Is it the timer?
-	case timer.TickMsg:
-		var cmd tea.Cmd
-		m.timer, cmd = m.timer.Update(msg)
-		return m, cmd
-
-	case timer.StartStopMsg:
-		var cmd tea.Cmd
-		m.timer, cmd = m.timer.Update(msg)
-		// m.keymap.stop.SetEnabled(m.timer.Running())
-		// m.keymap.start.SetEnabled(!m.timer.Running())
-		return m, cmd
-
-	case timer.TimeoutMsg:
-		m.setState(idTimer, false)
-		m.timerSpent = true
-		return m, nil
-
-	// Is it the progress Ticker?
-	case tickMsg:
-		if m.progress.Percent() == 1.0 {
-			return m, nil
-		}
-
-		// Note that you can also use progress.Model.SetPercent to set the
-		// percentage value explicitly, too.
-		cmd := m.progress.IncrPercent(0.25)
-		return m, tea.Batch(startProgress(), cmd)
-
-	// FrameMsg is sent when the progress bar wants to animate itself
-	case progress.FrameMsg:
-		progressModel, cmd := m.progress.Update(msg)
-		m.progress = progressModel.(progress.Model)
-		return m, cmd
 	}
 
 	return m, nil
@@ -183,34 +283,91 @@ func (m UtilsModel) handleCurrentChoice(msg tea.Msg) (UtilsModel, tea.Cmd) {
 	switch m.currentChoice().key {
 	case idTable:
 		if m.currentChoiceActive() {
-			m.table.Focus()
+			return m, m.data.Focus()
 		}
 
-		var cmd tea.Cmd
-		m.table, cmd = m.table.Update(tableFocusMsg())
-
-		return m, cmd
+		return m, nil
 	case idTimer:
 		if m.currentChoiceActive() {
-			return m, m.startTimer()
+			return m, m.timers.Focus()
+		}
+
+		return m, nil
+	case idProbe:
+		if m.currentChoiceActive() {
+			return m, m.probe.Focus()
+		}
+
+		return m, nil
+	case idMonitor:
+		if m.currentChoiceActive() {
+			return m, m.monitor.Focus()
 		}
 
-		if m.timer.Running() {
-			return m, m.stopTimer()
+		return m, nil
+	case idCerts:
+		if m.currentChoiceActive() {
+			return m, m.certs.Focus()
+		}
+
+		return m, nil
+	case idPorts:
+		if m.currentChoiceActive() {
+			return m, m.ports.Focus()
 		}
 
 		return m, nil
-	case idPing:
-		return m, pingGoogle()
 	case idProgress:
-		return m, startProgress()
+		if m.currentChoiceActive() {
+			return m, m.script.Focus()
+		}
+
+		return m, nil
+	case idLogs:
+		if m.currentChoiceActive() {
+			return m, m.logs.Focus()
+		}
+
+		return m, nil
 	default:
 		return m, nil
 	}
 }
 
 func (m UtilsModel) View() string {
-	s := []string{logoStyle.Render(logo)}
+	if m.logs.Focused() {
+		return lipgloss.JoinVertical(lipgloss.Top, m.headerView(), m.logs.View(), m.footerView())
+	}
+
+	if m.probe.Focused() {
+		return lipgloss.JoinVertical(lipgloss.Top, m.headerView(), m.probe.View(), m.footerView())
+	}
+
+	if m.monitor.Focused() {
+		return lipgloss.JoinVertical(lipgloss.Top, m.headerView(), m.monitor.View(), m.footerView())
+	}
+
+	if m.script.Focused() {
+		return lipgloss.JoinVertical(lipgloss.Top, m.headerView(), m.script.View(), m.footerView())
+	}
+
+	if m.timers.Focused() {
+		return lipgloss.JoinVertical(lipgloss.Top, m.headerView(), m.timers.View(), m.footerView())
+	}
+
+	if m.ports.Focused() {
+		return lipgloss.JoinVertical(lipgloss.Top, m.headerView(), m.ports.View(), m.footerView())
+	}
+
+	if m.certs.Focused() {
+		return lipgloss.JoinVertical(lipgloss.Top, m.headerView(), m.certs.View(), m.footerView())
+	}
+
+	if m.data.Focused() {
+		return lipgloss.JoinVertical(lipgloss.Top, m.headerView(), m.data.View(), m.footerView())
+	}
+
+	s := []string{m.headerView()}
 
 	for i, choice := range m.choices {
 		// Is the cursor pointing at this choice?
@@ -239,71 +396,49 @@ func (m UtilsModel) View() string {
 		}
 	}
 
-	// Table view
-	s = append(s, "\n", tableStyle.Render(m.table.View(), "\n"))
-	// return baseStyle.Render(m.table.View()) + "\n"
-
-	s = append(s, "\nTimer running "+m.timer.View())
+	// Timers keep running while the tool is closed
+	if timers := m.timers.Summary(); len(timers) > 0 {
+		s = append(s, "\n"+strings.Join(timers, "\n"))
+	}
 
 	// if !m.quitting {
 	// 	s = "Exiting in " + s
 	// 	s += m.helpView()
 	// }
 
-	// Progress bar view
-	s = append(s, "\n", m.progress.View(), "\n")
+	// Current or last script run, it carries on in the background
+	if run, ok := m.script.Summary(); ok {
+		s = append(s, "\n"+run)
+	}
+
+	// Latest uptime monitor transition, checks keep running in the background
+	if n, ok := m.monitor.Notification(); ok {
+		s = append(s, "\n"+n)
+	}
 
 	// The footer
-	s = append(s, titleStyle.Render("🡠 Esc to go back"))
+	s = append(s, m.footerView())
 
 	// Send the sting back to BubbleTea for rendering
 	return lipgloss.JoinVertical(lipgloss.Top, s...)
 }
 
-// Commands
-
-// Pings Google and returns a tea.Cmd that will send a message back to the root model
-type PingMsg string
-
-func pingGoogle() tea.Cmd {
-	url := "https://google.com"
-
-	return func() tea.Msg {
-		time.Sleep(5 * time.Second)
-		c := &http.Client{
-			Timeout: 5 * time.Second,
-		}
-
-		res, err := c.Get(url)
-		if err != nil {
-			var msg PingMsg = "ping:err"
-			return msg
-		}
-		defer res.Body.Close()
-
-		var msg PingMsg = "ping:ok"
-		log.Println("ping:ok")
-		return msg
-	}
+func (m UtilsModel) headerView() string {
+	return logoStyle.Render(logo)
 }
 
-func startProgress() tea.Cmd {
-	return tea.Tick(time.Second*1, func(t time.Time) tea.Msg {
-		return tickMsg(t)
-	})
+func (m UtilsModel) footerView() string {
+	return titleStyle.Render("🡠 Esc to go back")
 }
 
-func (m *UtilsModel) startTimer() tea.Cmd {
-	if m.timerSpent {
-		m.timer = timer.NewWithInterval(5*time.Second, time.Millisecond)
-		return m.timer.Init()
-	}
-
-	return m.timer.Start()
+// Typing reports whether a tool is capturing text input.
+func (m UtilsModel) Typing() bool {
+	return m.logs.Typing() || m.probe.Typing() || m.certs.Typing() || m.data.Typing() || m.timers.Typing() || m.script.Typing() || m.ports.Typing()
 }
 
-func (m UtilsModel) stopTimer() tea.Cmd {
-	return m.timer.Stop()
+// Stop kills a script that's still running when go-live quits.
+func (m UtilsModel) Stop() {
+	m.script.Stop()
 }
 
 func (m UtilsModel) choiceActive(c choice) bool {